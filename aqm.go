@@ -1,10 +1,11 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"fmt"
+	"math/rand"
 	"strconv"
 	"time"
 )
@@ -112,37 +113,44 @@ func newAqmPlot() *aqmPlot {
 // Start implements Starter.
 func (a *aqmPlot) Start(node Node) (err error) {
 	if PlotMarkProportion {
-		if err = a.propPlot.Open("mark-proportion.xpl"); err != nil {
+		n := plotName(node, "mark-proportion.xpl")
+		if err = a.propPlot.Open(n); err != nil {
 			return
 		}
 	}
 	if PlotMarkFrequency {
-		if err = a.freqPlot.Open("mark-frequency.xpl"); err != nil {
+		n := plotName(node, "mark-frequency.xpl")
+		if err = a.freqPlot.Open(n); err != nil {
 			return
 		}
 	}
 	if PlotSojourn {
-		if err = a.sojourn.Open("sojourn.xpl"); err != nil {
+		n := plotName(node, "sojourn.xpl")
+		if err = a.sojourn.Open(n); err != nil {
 			return
 		}
 	}
 	if PlotAdjSojourn {
-		if err = a.adjSojourn.Open("adj-sojourn.xpl"); err != nil {
+		n := plotName(node, "adj-sojourn.xpl")
+		if err = a.adjSojourn.Open(n); err != nil {
 			return
 		}
 	}
 	if PlotQueueLength {
-		if err = a.qlen.Open("queue-length.xpl"); err != nil {
+		n := plotName(node, "queue-length.xpl")
+		if err = a.qlen.Open(n); err != nil {
 			return
 		}
 	}
 	if PlotDeltaSigma {
-		if err = a.deltaSigma.Open("delta-sigma.xpl"); err != nil {
+		n := plotName(node, "delta-sigma.xpl")
+		if err = a.deltaSigma.Open(n); err != nil {
 			return
 		}
 	}
 	if PlotByteSeconds {
-		if err = a.byteSec.Open("queue-bytesec.xpl"); err != nil {
+		n := plotName(node, "queue-bytesec.xpl")
+		if err = a.byteSec.Open(n); err != nil {
 			return
 		}
 	}
@@ -305,3 +313,91 @@ func (a *aqmPlot) plotDeltaSigma(delta Clock, sigma Clock, now Clock) {
 		//f(now, strconv.FormatInt(int64(acc/1000), 10), colorWhite)
 	}
 }
+
+// ECNMode selects how an AQM interprets the ECT(1) codepoint, and so which
+// traffic gets its high-fidelity signal, and how that signal is applied.
+type ECNMode int
+
+const (
+	// SCEMode interprets ECT(1) as an SCE mark, which is applied to ECT
+	// packets from SCE capable senders.
+	SCEMode ECNMode = iota
+	// L4SMode interprets ECT(1) as the L4S identifier (RFC 9331).  L4S
+	// traffic gets the SCE signal as CE, and ECT(1) is never set.
+	L4SMode
+)
+
+// String implements fmt.Stringer.
+func (m ECNMode) String() string {
+	switch m {
+	case SCEMode:
+		return "SCE"
+	case L4SMode:
+		return "L4S"
+	}
+	return fmt.Sprintf("ECNMode(%d)", int(m))
+}
+
+// scalable returns true if the Packet should get the AQM's high-fidelity
+// signal.
+func (m ECNMode) scalable(pkt Packet) bool {
+	if m == L4SMode {
+		return pkt.ECN == ECT1 || pkt.ECN == CE
+	}
+	return bool(pkt.SCECapable) && pkt.ECN.ECT()
+}
+
+// apply applies the given mark to the Packet's ECN field, and returns the mark
+// actually applied.  SCE marks are only applied to scalable traffic that is
+// still ECT(0), so packets already SCE or CE marked aren't counted again, and
+// CE marks on Not-ECT packets are counted as drops.
+func (m ECNMode) apply(mk mark, pkt *Packet) mark {
+	switch mk {
+	case markSCE:
+		switch {
+		case !m.scalable(*pkt):
+			return markNone
+		case m == L4SMode:
+			pkt.ECN = CE
+			return markCE
+		case pkt.ECN != ECT0:
+			return markNone
+		}
+		pkt.ECN = ECT1
+	case markCE, markDrop:
+		if !pkt.ECN.ECT() {
+			mk = markDrop
+		}
+		// NOTE sender drop logic doesn't work yet, so we do a blind CE instead
+		pkt.ECN = CE
+	}
+	return mk
+}
+
+// byteMarker scales marks to packet length, for AQMs in byte mode, as in RFC
+// 7141.  Each packet that a mark is indicated for is marked with a probability
+// of its length over the largest packet length seen, so the decision stays
+// with the packet, and the flow, that the mark was indicated for.
+type byteMarker struct {
+	maxLen Bytes
+	rand   *rand.Rand
+}
+
+// newByteMarker returns a new byteMarker.
+func newByteMarker() byteMarker {
+	return byteMarker{
+		0,                                 // maxLen
+		rand.New(rand.NewSource(AQMSeed)), // rand
+	}
+}
+
+// mark returns true if a packet with the given length should be marked, where
+// indicated is true if the AQM indicated a mark for it.  Without AQMByteMode,
+// or for packets of the largest length seen, this is indicated.
+func (b *byteMarker) mark(indicated bool, length Bytes) bool {
+	b.maxLen = max(b.maxLen, length)
+	if !indicated || !AQMByteMode || length >= b.maxLen {
+		return indicated
+	}
+	return b.rand.Float64() < float64(length)/float64(b.maxLen)
+}
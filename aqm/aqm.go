@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+// Package aqm defines the exported AQM interface for scim, so that AQMs may be
+// kept in external modules.  An AQM is used as the queue for an interface, and
+// sees each Packet on enqueue and dequeue, where it may mark it by setting its
+// ECN codepoint.  The sim.Node gives access to the current time, timers and
+// logging.  AQMs may also implement sim.Starter, sim.Dinger and sim.Stopper.
+// Timers the AQM starts with sim.Node's Timer are passed to its Ding, which it
+// must implement if it starts any.
+package aqm
+
+import (
+	"github.com/heistp/scim/sim"
+)
+
+// AQM implements Active Queue Management.
+type AQM interface {
+	// Enqueue adds a Packet to the queue.
+	Enqueue(sim.Packet, sim.Node)
+	// Dequeue removes and returns the Packet at the head of the queue, with
+	// ok false if the queue is empty.
+	Dequeue(sim.Node) (pkt sim.Packet, ok bool)
+	// Peek returns the Packet at the head of the queue without removing it,
+	// with ok false if the queue is empty.
+	Peek(sim.Node) (pkt sim.Packet, ok bool)
+	// Len returns the number of packets in the queue.
+	Len() int
+}
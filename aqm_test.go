@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"testing"
+	"time"
+)
+
+func TestByteMarker(t *testing.T) {
+	defer func(m bool) { AQMByteMode = m }(AQMByteMode)
+	const n = 3000
+	tests := []struct {
+		name     string
+		byteMode bool
+		indicate func(length Bytes) bool
+		minSmall int // min marks on small packets
+		maxSmall int // max marks on small packets
+		large    int // marks on large packets
+	}{
+		{"packet mode", false, func(Bytes) bool { return true }, n, n, n},
+		{"byte mode", true, func(Bytes) bool { return true }, 300, 500, n},
+		{"byte mode small only", true, func(l Bytes) bool { return l == 200 },
+			300, 500, 0},
+		{"byte mode large only", true, func(l Bytes) bool { return l == 1500 },
+			0, 0, n},
+	}
+	for _, c := range tests {
+		AQMByteMode = c.byteMode
+		b := newByteMarker()
+		var small, large int
+		for i := 0; i < n; i++ {
+			for _, l := range []Bytes{1500, 200} {
+				if !b.mark(c.indicate(l), l) {
+					continue
+				}
+				if l == 200 {
+					small++
+				} else {
+					large++
+				}
+			}
+		}
+		if small < c.minSmall || small > c.maxSmall {
+			t.Errorf("%s: %d marks on small packets, want %d to %d", c.name,
+				small, c.minSmall, c.maxSmall)
+		}
+		if large != c.large {
+			t.Errorf("%s: %d marks on large packets, want %d", c.name, large,
+				c.large)
+		}
+	}
+}
+
+func TestBrickwallByteMode(t *testing.T) {
+	defer func(m bool) { AQMByteMode = m }(AQMByteMode)
+	AQMByteMode = true
+	const n = 1000
+	b := NewBrickwall(0, Clock(time.Millisecond), 0, SCEMode)
+	node := &testNode{}
+	for i := 0; i < n; i++ {
+		b.Enqueue(Packet{Flow: 0, Len: 1500, ECN: ECT0}, node)
+		b.Enqueue(Packet{Flow: 1, Len: 200, ECN: ECT0}, node)
+	}
+	node.now = Clock(10 * time.Millisecond)
+	var ce [2]int
+	for {
+		p, ok := b.Dequeue(node)
+		if !ok {
+			break
+		}
+		if p.ECN == CE {
+			ce[p.Flow]++
+		}
+	}
+	if ce[0] != n {
+		t.Errorf("%d CE marks for 1500 byte packets, want %d", ce[0], n)
+	}
+	// expect about n * 200 / 1500, or 133
+	if ce[1] < 90 || ce[1] > 180 {
+		t.Errorf("%d CE marks for 200 byte packets, want 90 to 180", ce[1])
+	}
+}
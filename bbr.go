@@ -0,0 +1,830 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BBR constants common to all configurations.
+const (
+	bbrMinCwndSegs   = 4     // minimum cwnd and ProbeRTT cwnd for v1, in MSS
+	bbrPacingMargin  = 0.01  // pace at 1% below the estimated bandwidth
+	bbrFullBwGrowth  = 1.25  // required bandwidth growth in Startup
+	bbrFullBwRounds  = 3     // rounds without growth to exit Startup
+	bbrV1CycleLen    = 8     // length of BBRv1 ProbeBW gain cycle
+	bbrV1CycleRand   = 7     // random initial ProbeBW cycle phases for v1
+	bbrV1HighGain    = 2.885 // 2/ln(2), Startup gain for v1
+	bbrV3StartupGain = 2.77  // 4*ln(2), Startup pacing gain for v3
+	bbrV3StartupCwnd = 2.0   // Startup cwnd gain for v3
+	bbrV3DrainGain   = 0.35  // Drain pacing gain for v3
+	bbrCwndGain      = 2.0   // default cwnd gain for ProbeBW
+	bbrV3UpCwndGain  = 2.25  // cwnd gain for ProbeBW_UP
+)
+
+// bbrV1CycleGains are the BBRv1 ProbeBW pacing gains.
+var bbrV1CycleGains = [bbrV1CycleLen]float64{1.25, 0.75, 1, 1, 1, 1, 1, 1}
+
+// bbrState is the BBR state.
+type bbrState int
+
+const (
+	bbrStartup bbrState = iota
+	bbrDrain
+	bbrProbeBW
+	bbrProbeRTT
+)
+
+// String implements fmt.Stringer.
+func (s bbrState) String() string {
+	switch s {
+	case bbrStartup:
+		return "Startup"
+	case bbrDrain:
+		return "Drain"
+	case bbrProbeBW:
+		return "ProbeBW"
+	case bbrProbeRTT:
+		return "ProbeRTT"
+	}
+	return "Unknown"
+}
+
+// bbrPhase is the BBRv3 ProbeBW phase.
+type bbrPhase int
+
+const (
+	bbrDown bbrPhase = iota
+	bbrCruise
+	bbrRefill
+	bbrUp
+)
+
+// bbrAckPhase is the BBRv3 ACK phase, relative to bandwidth probing.
+type bbrAckPhase int
+
+const (
+	bbrAcksInit bbrAckPhase = iota
+	bbrAcksRefilling
+	bbrAcksProbeStarting
+	bbrAcksProbeFeedback
+	bbrAcksProbeStopping
+)
+
+// BBR implements the BBR CCA, either version 1
+// (draft-cardwell-iccrg-bbr-congestion-control-00), or version 3
+// (draft-ietf-ccwg-bbr) with the ECN response from the Linux implementation.
+// BBR paces at a rate derived from its model of the bottleneck bandwidth and
+// min RTT, and bounds cwnd to a multiple of the BDP.  It runs its own Startup,
+// so BBR flows should be configured with NoSS, NoResponse and Pacing.  Since
+// drops are not yet supported by the sender, only the ECN response applies
+// for v3.
+type BBR struct {
+	version    int
+	state      bbrState
+	pacingGain float64
+	cwndGain   float64
+	rand       *rand.Rand
+	mss        Bytes // the flow's MSS, set on init
+	// model
+	maxBw            maxFilter
+	cycleCount       int
+	minRtt           Clock
+	minRttStamp      Clock
+	probeRttMin      Clock
+	probeRttMinStamp Clock
+	probeRttExpired  bool
+	// round counting
+	nextRoundDelivered Bytes
+	roundCount         int
+	roundStart         bool
+	cwndLimited        bool
+	// Startup
+	fullBw      Bitrate
+	fullBwCount int
+	fullBwNow   bool
+	filledPipe  bool
+	// ProbeBW
+	cycleIndex       int
+	cycleStamp       Clock
+	phase            bbrPhase
+	ackPhase         bbrAckPhase
+	probeWait        Clock
+	roundsSinceProbe int
+	probeUpCnt       Bytes
+	probeUpAcks      Bytes
+	probeUpRounds    int
+	bwProbeSamples   bool
+	inflightHi       Bytes
+	inflightLo       Bytes
+	// ProbeRTT
+	probeRttDone      Clock
+	probeRttRoundDone bool
+	priorCwnd         Bytes
+	// ECN
+	ecnAlpha         float64
+	roundDelivered   Bytes
+	roundCE          Bytes
+	startupECNRounds int
+}
+
+// NewBBRv1 returns a new BBR version 1.
+func NewBBRv1() *BBR {
+	return newBBR(1)
+}
+
+// NewBBRv3 returns a new BBR version 3.
+func NewBBRv3() *BBR {
+	return newBBR(3)
+}
+
+// newBBR returns a new BBR with the given version.
+func newBBR(version int) *BBR {
+	return &BBR{
+		version,                           // version
+		bbrStartup,                        // state
+		1.0,                               // pacingGain
+		1.0,                               // cwndGain
+		rand.New(rand.NewSource(CCASeed)), // rand
+		MSS,                               // mss
+		maxFilter{},                       // maxBw
+		0,                                 // cycleCount
+		ClockMax,                          // minRtt
+		0,                                 // minRttStamp
+		ClockMax,                          // probeRttMin
+		0,                                 // probeRttMinStamp
+		false,                             // probeRttExpired
+		0,                                 // nextRoundDelivered
+		0,                                 // roundCount
+		false,                             // roundStart
+		false,                             // cwndLimited
+		0,                                 // fullBw
+		0,                                 // fullBwCount
+		false,                             // fullBwNow
+		false,                             // filledPipe
+		0,                                 // cycleIndex
+		0,                                 // cycleStamp
+		bbrDown,                           // phase
+		bbrAcksInit,                       // ackPhase
+		0,                                 // probeWait
+		0,                                 // roundsSinceProbe
+		MaxBytes,                          // probeUpCnt
+		0,                                 // probeUpAcks
+		0,                                 // probeUpRounds
+		false,                             // bwProbeSamples
+		MaxBytes,                          // inflightHi
+		MaxBytes,                          // inflightLo
+		0,                                 // probeRttDone
+		false,                             // probeRttRoundDone
+		0,                                 // priorCwnd
+		1.0,                               // ecnAlpha
+		0,                                 // roundDelivered
+		0,                                 // roundCE
+		0,                                 // startupECNRounds
+	}
+}
+
+// slowStartExit implements slowStartExiter.  BBR is initialized here, as
+// flows using it exit slow-start on the first ACK.
+func (b *BBR) slowStartExit(flow *Flow, node Node) {
+	now := node.Now()
+	b.mss = flow.mss()
+	b.minRtt = flow.minRtt
+	b.minRttStamp = now
+	b.probeRttMin = flow.minRtt
+	b.probeRttMinStamp = now
+	b.startRound(flow)
+	b.enterStartup()
+	r := flow.srtt
+	if r == 0 {
+		r = Clock(time.Millisecond)
+	}
+	flow.pacingRate = Bitrate(b.pacingGain *
+		float64(CalcBitrate(flow.cwnd, time.Duration(r))))
+}
+
+// grow implements CCA.
+func (b *BBR) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	rs := flow.rs
+	b.cwndLimited = flow.inFlight+acked+b.mss > flow.cwnd
+	b.updateRound(rs, flow)
+	if b.version >= 3 {
+		b.updateECN(flow, node)
+	}
+	b.updateMaxBw(rs)
+	b.checkFullBw(rs)
+	b.checkStartupDone(flow, node)
+	b.checkDrainDone(flow, node)
+	if b.version >= 3 {
+		b.updateProbeBWPhase(rs, flow, node)
+	} else {
+		b.updateCycle(acked, flow, node)
+	}
+	b.updateMinRtt(rs, node.Now())
+	b.checkProbeRTT(flow, node)
+	b.setPacingRate(flow)
+	b.setCwnd(acked, flow, node)
+}
+
+// bw returns the bandwidth estimate.
+func (b *BBR) bw() Bitrate {
+	return b.maxBw.get()
+}
+
+// inflight returns the in-flight bytes for the given gain, including the
+// quantization budget.
+func (b *BBR) inflight(gain float64) Bytes {
+	if b.minRtt == ClockMax || b.bw() == 0 {
+		return IWSegs * b.mss
+	}
+	bdp := b.bw().Yps() * time.Duration(b.minRtt).Seconds()
+	return Bytes(gain*bdp) + 3*b.mss
+}
+
+// targetInflight returns the smaller of the BDP and cwnd.
+func (b *BBR) targetInflight(flow *Flow) Bytes {
+	return min(b.inflight(1.0), flow.cwnd)
+}
+
+// setPacingRate sets the Flow's pacing rate from the bandwidth estimate.  In
+// Startup, the pacing rate is never reduced.
+func (b *BBR) setPacingRate(flow *Flow) {
+	if b.bw() == 0 {
+		return
+	}
+	r := Bitrate(b.pacingGain * float64(b.bw()) * (1 - bbrPacingMargin))
+	if b.filledPipe || r > flow.pacingRate {
+		flow.pacingRate = r
+	}
+}
+
+// setCwnd sets the Flow's cwnd from the in-flight target.
+func (b *BBR) setCwnd(acked Bytes, flow *Flow, node Node) {
+	t := b.inflight(b.cwndGain)
+	c := flow.cwnd
+	if b.filledPipe {
+		c = min(c+acked, t)
+	} else if c < t || flow.delivered < IWSegs*b.mss {
+		c += acked
+	}
+	c = max(c, b.minCwnd())
+	if b.state == bbrProbeRTT {
+		c = min(c, b.probeRttCwnd())
+	}
+	if b.version >= 3 {
+		c = b.boundCwnd(c)
+	}
+	if b.state == bbrProbeRTT {
+		flow.setCWNDFor(causeProbe, c, node)
+		return
+	}
+	flow.setCWND(c, node)
+}
+
+// boundCwnd returns cwnd bounded by the BBRv3 in-flight model.
+func (b *BBR) boundCwnd(cwnd Bytes) Bytes {
+	c := Bytes(MaxBytes)
+	switch {
+	case b.state == bbrProbeBW && b.phase != bbrCruise:
+		c = b.inflightHi
+	case b.state == bbrProbeRTT || b.state == bbrProbeBW:
+		c = b.inflightWithHeadroom()
+	}
+	c = max(min(c, b.inflightLo), b.minCwnd())
+	return min(cwnd, c)
+}
+
+// updateRound updates the round trip counter.
+func (b *BBR) updateRound(rs rateSample, flow *Flow) {
+	b.roundStart = false
+	if rs.newlyAcked > 0 && rs.priorDelivered >= b.nextRoundDelivered {
+		b.startRound(flow)
+		b.roundCount++
+		b.roundsSinceProbe++
+		b.roundStart = true
+	}
+}
+
+// startRound starts a new round trip.
+func (b *BBR) startRound(flow *Flow) {
+	b.nextRoundDelivered = flow.delivered
+}
+
+// updateMaxBw updates the max bandwidth filter from the rate sample.
+// App-limited samples are only used if they increase the max.
+func (b *BBR) updateMaxBw(rs rateSample) {
+	if rs.deliveryRate == 0 {
+		return
+	}
+	if rs.appLimited && rs.deliveryRate < b.bw() {
+		return
+	}
+	if b.version >= 3 {
+		b.maxBw.update(2, b.cycleCount, rs.deliveryRate)
+	} else {
+		b.maxBw.update(BBRv1BwFilterLen, b.roundCount, rs.deliveryRate)
+	}
+}
+
+// checkFullBw checks whether the bandwidth has stopped growing, which for v1
+// is checked once per round in Startup, and for v3 also during ProbeBW_UP.
+func (b *BBR) checkFullBw(rs rateSample) {
+	if rs.appLimited {
+		return
+	}
+	if b.version >= 3 {
+		if b.fullBwNow {
+			return
+		}
+		if float64(rs.deliveryRate) >= float64(b.fullBw)*bbrFullBwGrowth {
+			b.resetFullBw()
+			b.fullBw = rs.deliveryRate
+			return
+		}
+	} else {
+		if b.filledPipe || !b.roundStart {
+			return
+		}
+		if float64(b.bw()) >= float64(b.fullBw)*bbrFullBwGrowth {
+			b.fullBw = b.bw()
+			b.fullBwCount = 0
+			return
+		}
+	}
+	if !b.roundStart {
+		return
+	}
+	b.fullBwCount++
+	if b.fullBwCount >= bbrFullBwRounds {
+		b.fullBwNow = true
+		b.filledPipe = true
+	}
+}
+
+// resetFullBw resets the full bandwidth detection state.
+func (b *BBR) resetFullBw() {
+	b.fullBw = 0
+	b.fullBwCount = 0
+	b.fullBwNow = false
+}
+
+// checkStartupDone enters Drain if the pipe is full in Startup.
+func (b *BBR) checkStartupDone(flow *Flow, node Node) {
+	if b.state == bbrStartup && b.filledPipe {
+		b.enterDrain(flow, node)
+	}
+}
+
+// checkDrainDone enters ProbeBW once the queue is drained.
+func (b *BBR) checkDrainDone(flow *Flow, node Node) {
+	if b.state == bbrDrain && flow.inFlight <= b.inflight(1.0) {
+		b.enterProbeBW(flow, node)
+	}
+}
+
+// enterStartup enters the Startup state.
+func (b *BBR) enterStartup() {
+	b.state = bbrStartup
+	if b.version >= 3 {
+		b.pacingGain = bbrV3StartupGain
+		b.cwndGain = bbrV3StartupCwnd
+	} else {
+		b.pacingGain = bbrV1HighGain
+		b.cwndGain = bbrV1HighGain
+	}
+}
+
+// enterDrain enters the Drain state.
+func (b *BBR) enterDrain(flow *Flow, node Node) {
+	b.setState(bbrDrain, flow, node)
+	if b.version >= 3 {
+		b.pacingGain = bbrV3DrainGain
+		b.cwndGain = bbrV3StartupCwnd
+	} else {
+		b.pacingGain = 1 / bbrV1HighGain
+		b.cwndGain = bbrV1HighGain
+	}
+}
+
+// enterProbeBW enters the ProbeBW state.
+func (b *BBR) enterProbeBW(flow *Flow, node Node) {
+	b.setState(bbrProbeBW, flow, node)
+	b.cwndGain = bbrCwndGain
+	if b.version >= 3 {
+		b.startDown(flow, node)
+		return
+	}
+	b.cycleIndex = bbrV1CycleLen - 1 - b.rand.Intn(bbrV1CycleRand)
+	b.advanceCycle(node.Now())
+}
+
+// setState sets the state and logs the transition.
+func (b *BBR) setState(state bbrState, flow *Flow, node Node) {
+	node.Logf("flow:%d bbr %s->%s bw:%.3fMbps minrtt:%sms cwnd:%d",
+		flow.id, b.state, state, b.bw().Mbps(), b.minRtt.StringMS(),
+		flow.cwnd)
+	flow.traceStage(b.state, state, node)
+	b.state = state
+}
+
+// updateCycle advances the BBRv1 ProbeBW gain cycle when it's time.
+func (b *BBR) updateCycle(acked Bytes, flow *Flow, node Node) {
+	if b.state != bbrProbeBW {
+		return
+	}
+	p := flow.inFlight + acked
+	full := node.Now()-b.cycleStamp > b.minRtt
+	var next bool
+	switch g := b.pacingGain; {
+	case g > 1:
+		next = full && p >= b.inflight(g)
+	case g < 1:
+		next = full || p <= b.inflight(1.0)
+	default:
+		next = full
+	}
+	if next {
+		b.advanceCycle(node.Now())
+		if b.pacingGain > 1 {
+			flow.traceProbe("bandwidth", node)
+		}
+	}
+}
+
+// advanceCycle advances to the next BBRv1 ProbeBW gain cycle phase.
+func (b *BBR) advanceCycle(now Clock) {
+	b.cycleIndex = (b.cycleIndex + 1) % bbrV1CycleLen
+	b.cycleStamp = now
+	b.pacingGain = bbrV1CycleGains[b.cycleIndex]
+}
+
+// updateProbeBWPhase updates the BBRv3 ProbeBW phase.
+func (b *BBR) updateProbeBWPhase(rs rateSample, flow *Flow, node Node) {
+	if !b.filledPipe {
+		return
+	}
+	b.adaptUpperBounds(rs, flow, node)
+	if b.state != bbrProbeBW {
+		return
+	}
+	switch b.phase {
+	case bbrDown:
+		if b.isTimeToProbeBW(flow, node) {
+			return
+		}
+		if b.isTimeToCruise(flow) {
+			b.startCruise()
+		}
+	case bbrCruise:
+		b.isTimeToProbeBW(flow, node)
+	case bbrRefill:
+		if b.roundStart {
+			b.startUp(rs, flow)
+		}
+	case bbrUp:
+		if b.isTimeToGoDown(rs, flow) {
+			b.startDown(flow, node)
+		}
+	}
+}
+
+// startDown starts the ProbeBW_DOWN phase.
+func (b *BBR) startDown(flow *Flow, node Node) {
+	b.bwProbeSamples = false
+	b.probeUpCnt = MaxBytes
+	b.roundsSinceProbe = b.rand.Intn(2)
+	b.probeWait = Clock(2*time.Second) +
+		Clock(b.rand.Int63n(int64(time.Second)))
+	b.cycleStamp = node.Now()
+	b.ackPhase = bbrAcksProbeStopping
+	b.startRound(flow)
+	b.phase = bbrDown
+	b.pacingGain = 0.9
+	b.cwndGain = bbrCwndGain
+}
+
+// startCruise starts the ProbeBW_CRUISE phase.
+func (b *BBR) startCruise() {
+	b.phase = bbrCruise
+	b.pacingGain = 1.0
+}
+
+// startRefill starts the ProbeBW_REFILL phase.
+func (b *BBR) startRefill(flow *Flow) {
+	b.inflightLo = MaxBytes
+	b.probeUpRounds = 0
+	b.probeUpAcks = 0
+	b.ackPhase = bbrAcksRefilling
+	b.startRound(flow)
+	b.phase = bbrRefill
+	b.pacingGain = 1.0
+}
+
+// startUp starts the ProbeBW_UP phase.
+func (b *BBR) startUp(rs rateSample, flow *Flow) {
+	b.bwProbeSamples = true
+	b.ackPhase = bbrAcksProbeStarting
+	b.startRound(flow)
+	b.resetFullBw()
+	b.fullBw = rs.deliveryRate
+	b.phase = bbrUp
+	b.pacingGain = 1.25
+	b.cwndGain = bbrV3UpCwndGain
+	b.raiseInflightHiSlope(flow)
+}
+
+// isTimeToProbeBW starts ProbeBW_REFILL and returns true if it's time to
+// probe for bandwidth, either after the randomized wait, or after the number
+// of rounds it would take Reno to probe.
+func (b *BBR) isTimeToProbeBW(flow *Flow, node Node) bool {
+	r := min(int(b.targetInflight(flow)/b.mss), 63)
+	if node.Now()-b.cycleStamp > b.probeWait || b.roundsSinceProbe >= r {
+		b.startRefill(flow)
+		flow.traceProbe("bandwidth", node)
+		return true
+	}
+	return false
+}
+
+// isTimeToCruise returns true if in-flight has fallen enough to cruise.
+func (b *BBR) isTimeToCruise(flow *Flow) bool {
+	if flow.inFlight > b.inflightWithHeadroom() {
+		return false
+	}
+	return flow.inFlight <= b.inflight(1.0)
+}
+
+// isTimeToGoDown returns true if bandwidth probing should stop.
+func (b *BBR) isTimeToGoDown(rs rateSample, flow *Flow) bool {
+	if b.cwndLimited && flow.cwnd >= b.inflightHi {
+		b.resetFullBw()
+		b.fullBw = rs.deliveryRate
+	} else if b.fullBwNow {
+		return true
+	}
+	return false
+}
+
+// adaptUpperBounds updates the ACK phase and inflight_hi.
+func (b *BBR) adaptUpperBounds(rs rateSample, flow *Flow, node Node) {
+	if b.ackPhase == bbrAcksProbeStarting && b.roundStart {
+		b.ackPhase = bbrAcksProbeFeedback
+	}
+	if b.ackPhase == bbrAcksProbeStopping && b.roundStart {
+		if b.state == bbrProbeBW {
+			b.cycleCount++
+		}
+		b.ackPhase = bbrAcksInit
+	}
+	if b.checkInflightTooHigh(rs, flow, node) {
+		return
+	}
+	if b.inflightHi == MaxBytes {
+		return
+	}
+	if rs.txInFlight > b.inflightHi {
+		b.inflightHi = rs.txInFlight
+	}
+	if b.state == bbrProbeBW && b.phase == bbrUp {
+		b.probeInflightHiUpward(rs, flow)
+	}
+}
+
+// checkInflightTooHigh returns true if the rate sample shows that in-flight
+// was too high, in which case inflight_hi is reduced if bandwidth probing.
+// Since there are no losses, only the CE marked fraction is checked.
+func (b *BBR) checkInflightTooHigh(rs rateSample, flow *Flow,
+	node Node) bool {
+	if !b.ecnEligible(flow) || rs.delivered == 0 ||
+		float64(rs.deliveredCE) <= float64(rs.delivered)*BBRv3ECNThresh {
+		return false
+	}
+	if b.bwProbeSamples {
+		b.inflightHi = max(rs.txInFlight,
+			Bytes(float64(b.targetInflight(flow))*BBRv3Beta))
+		if b.state == bbrProbeBW && b.phase == bbrUp {
+			b.startDown(flow, node)
+		}
+	}
+	return true
+}
+
+// probeInflightHiUpward grows inflight_hi while probing, when cwnd limited.
+func (b *BBR) probeInflightHiUpward(rs rateSample, flow *Flow) {
+	if !b.cwndLimited || flow.cwnd < b.inflightHi {
+		return
+	}
+	b.probeUpAcks += rs.newlyAcked
+	if b.probeUpAcks >= b.probeUpCnt {
+		d := b.probeUpAcks / b.probeUpCnt
+		b.probeUpAcks -= d * b.probeUpCnt
+		b.inflightHi += d * b.mss
+	}
+	if b.roundStart {
+		b.raiseInflightHiSlope(flow)
+	}
+}
+
+// raiseInflightHiSlope doubles the growth of inflight_hi each round.
+func (b *BBR) raiseInflightHiSlope(flow *Flow) {
+	g := Bytes(1) << b.probeUpRounds
+	b.probeUpRounds = min(b.probeUpRounds+1, 30)
+	b.probeUpCnt = max(flow.cwnd/g, b.mss)
+}
+
+// inflightWithHeadroom returns inflight_hi less some headroom, to leave space
+// for other flows.
+func (b *BBR) inflightWithHeadroom() Bytes {
+	if b.inflightHi == MaxBytes {
+		return MaxBytes
+	}
+	h := max(b.mss, Bytes(BBRv3Headroom*float64(b.inflightHi)))
+	return max(b.inflightHi-h, b.minCwnd())
+}
+
+// ecnEligible returns true if BBRv3 should respond to ECN.
+func (b *BBR) ecnEligible(flow *Flow) bool {
+	return flow.ecn != NoECN &&
+		(BBRv3ECNMaxRTT == 0 || b.minRtt <= BBRv3ECNMaxRTT)
+}
+
+// updateECN updates the ECN alpha once per round, which is the EWMA of the
+// fraction of CE marked bytes, and responds to it.  In Startup, the pipe is
+// considered full after rounds with a high CE fraction.  Outside of bandwidth
+// probing, inflight_lo is reduced in proportion to alpha after rounds with CE.
+func (b *BBR) updateECN(flow *Flow, node Node) {
+	if !b.roundStart {
+		return
+	}
+	d := flow.delivered - b.roundDelivered
+	c := flow.deliveredCE - b.roundCE
+	b.roundDelivered = flow.delivered
+	b.roundCE = flow.deliveredCE
+	if !b.ecnEligible(flow) || d <= 0 {
+		return
+	}
+	r := float64(c) / float64(d)
+	b.ecnAlpha = (1-BBRv3ECNGain)*b.ecnAlpha + BBRv3ECNGain*r
+	if !b.filledPipe {
+		if r >= BBRv3ECNThresh {
+			b.startupECNRounds++
+		} else {
+			b.startupECNRounds = 0
+		}
+		if b.startupECNRounds >= BBRv3FullECNRounds {
+			b.filledPipe = true
+			b.fullBwNow = true
+			b.inflightHi = b.inflight(1.0)
+		}
+		return
+	}
+	if c > 0 && !b.isProbingBW() {
+		l := b.inflightLo
+		if l == MaxBytes {
+			l = flow.cwnd
+		}
+		l = Bytes(float64(l) * (1 - b.ecnAlpha*BBRv3ECNFactor))
+		b.inflightLo = max(l, b.minCwnd())
+	}
+}
+
+// isProbingBW returns true if BBR is probing for bandwidth.
+func (b *BBR) isProbingBW() bool {
+	return b.state == bbrStartup || (b.state == bbrProbeBW &&
+		(b.phase == bbrRefill || b.phase == bbrUp))
+}
+
+// updateMinRtt updates the min RTT and ProbeRTT min RTT filters.
+func (b *BBR) updateMinRtt(rs rateSample, now Clock) {
+	b.probeRttExpired = now > b.probeRttMinStamp+b.probeRttInterval()
+	if rs.rtt > 0 && (rs.rtt < b.probeRttMin || b.probeRttExpired) {
+		b.probeRttMin = rs.rtt
+		b.probeRttMinStamp = now
+	}
+	e := now > b.minRttStamp+BBRMinRTTFilterLen
+	if b.probeRttMin < b.minRtt || e {
+		b.minRtt = b.probeRttMin
+		b.minRttStamp = b.probeRttMinStamp
+	}
+}
+
+// probeRttInterval returns the maximum interval between ProbeRTTs.
+func (b *BBR) probeRttInterval() Clock {
+	if b.version >= 3 {
+		return BBRv3ProbeRTTInterval
+	}
+	return BBRMinRTTFilterLen
+}
+
+// minCwnd returns the minimum cwnd, which is also the ProbeRTT cwnd for v1.
+func (b *BBR) minCwnd() Bytes {
+	return bbrMinCwndSegs * b.mss
+}
+
+// probeRttCwnd returns the cwnd used during ProbeRTT.
+func (b *BBR) probeRttCwnd() Bytes {
+	if b.version >= 3 {
+		return max(b.inflight(0.5), b.minCwnd())
+	}
+	return b.minCwnd()
+}
+
+// checkProbeRTT enters ProbeRTT if the ProbeRTT min RTT has expired, and
+// handles the ProbeRTT state.
+func (b *BBR) checkProbeRTT(flow *Flow, node Node) {
+	if b.state != bbrProbeRTT && b.probeRttExpired {
+		b.setState(bbrProbeRTT, flow, node)
+		flow.traceProbe("rtt", node)
+		b.pacingGain = 1.0
+		b.cwndGain = 1.0
+		b.priorCwnd = flow.cwnd
+		b.probeRttDone = 0
+		if b.version >= 3 {
+			b.ackPhase = bbrAcksProbeStopping
+			b.startRound(flow)
+		}
+	}
+	if b.state != bbrProbeRTT {
+		return
+	}
+	flow.setAppLimited()
+	now := node.Now()
+	if b.probeRttDone == 0 && flow.inFlight <= b.probeRttCwnd() {
+		b.probeRttDone = now + BBRProbeRTTDuration
+		b.probeRttRoundDone = false
+		b.startRound(flow)
+	} else if b.probeRttDone != 0 {
+		if b.roundStart {
+			b.probeRttRoundDone = true
+		}
+		if b.probeRttRoundDone && now > b.probeRttDone {
+			b.probeRttMinStamp = now
+			c := max(flow.cwnd, b.priorCwnd)
+			flow.setCWNDFor(causeProbe, c, node)
+			b.exitProbeRTT(flow, node)
+		}
+	}
+}
+
+// exitProbeRTT exits ProbeRTT to ProbeBW if the pipe is full, or Startup
+// otherwise.
+func (b *BBR) exitProbeRTT(flow *Flow, node Node) {
+	if !b.filledPipe {
+		b.setState(bbrStartup, flow, node)
+		b.enterStartup()
+		return
+	}
+	b.enterProbeBW(flow, node)
+	if b.version >= 3 {
+		b.inflightLo = MaxBytes
+		b.startCruise()
+	}
+}
+
+// maxFilter is a windowed max filter over a time counter such as round trips,
+// using the algorithm from Linux's lib/win_minmax.c, which tracks the best,
+// second best and third best samples.
+type maxFilter struct {
+	s [3]maxSample
+}
+
+// maxSample is one sample in a maxFilter.
+type maxSample struct {
+	t int
+	v Bitrate
+}
+
+// get returns the max value.
+func (m *maxFilter) get() Bitrate {
+	return m.s[0].v
+}
+
+// update adds a sample with the given window length and time, and returns the
+// max value.
+func (m *maxFilter) update(win, t int, v Bitrate) Bitrate {
+	n := maxSample{t, v}
+	if v >= m.s[0].v || t-m.s[2].t > win {
+		m.s = [3]maxSample{n, n, n}
+		return v
+	}
+	if v >= m.s[1].v {
+		m.s[1], m.s[2] = n, n
+	} else if v >= m.s[2].v {
+		m.s[2] = n
+	}
+	d := t - m.s[0].t
+	switch {
+	case d > win:
+		m.s[0], m.s[1], m.s[2] = m.s[1], m.s[2], n
+		if t-m.s[0].t > win {
+			m.s[0], m.s[1] = m.s[1], m.s[2]
+		}
+	case m.s[1].t == m.s[0].t && float64(d) > float64(win)/4:
+		m.s[1], m.s[2] = n, n
+	case m.s[2].t == m.s[1].t && float64(d) > float64(win)/2:
+		m.s[2] = n
+	}
+	return m.s[0].v
+}
@@ -1,26 +1,33 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
-// Brickwall implements an AQM that marks or drops at given thresholds.
+// Brickwall implements an AQM that marks or drops at given thresholds.  With
+// AQMByteMode, packets above a threshold are marked with a probability in
+// proportion to their length, rather than all being marked.
 type Brickwall struct {
 	queue      []Packet
 	sceTarget  Clock
 	ceTarget   Clock
 	dropTarget Clock
+	mode       ECNMode
+	marker     byteMarker
 	// Plots
 	*aqmPlot
 }
 
 // NewBrickwall returns a new Brickwall.
-func NewBrickwall(sceTarget, ceTarget, dropTarget Clock) *Brickwall {
+func NewBrickwall(sceTarget, ceTarget, dropTarget Clock,
+	mode ECNMode) *Brickwall {
 	p := newAqmPlot()
 	return &Brickwall{
 		make([]Packet, 0), // queue
 		sceTarget,         // sceTarget
 		ceTarget,          // ceTarget
 		dropTarget,        // dropTarget
+		mode,              // mode
+		newByteMarker(),   // marker
 		p,                 // aqmPlot
 	}
 }
@@ -47,19 +54,17 @@ func (b *Brickwall) Dequeue(node Node) (pkt Packet, ok bool) {
 
 	s := node.Now() - pkt.Enqueue
 	ok = true
+	sce := b.marker.mark(b.sceTarget > 0 && s > b.sceTarget, pkt.Len)
+	ce := b.marker.mark(b.ceTarget > 0 && s > b.ceTarget, pkt.Len)
+	drop := b.marker.mark(b.dropTarget > 0 && s > b.dropTarget, pkt.Len)
 	var m mark
-	if b.dropTarget > 0 && s > b.dropTarget {
+	if drop {
 		// ok = false
-		m = markDrop
-		pkt.CE = true
-	} else if b.ceTarget > 0 && s > b.ceTarget {
-		m = markCE
-		pkt.CE = true
-	} else if b.sceTarget > 0 && s > b.sceTarget {
-		if pkt.SCECapable {
-			m = markSCE
-			pkt.SCE = true
-		}
+		m = b.mode.apply(markDrop, &pkt)
+	} else if ce {
+		m = b.mode.apply(markCE, &pkt)
+	} else if sce {
+		m = b.mode.apply(markSCE, &pkt)
 	}
 
 	b.plotSojourn(node.Now()-pkt.Enqueue, len(b.queue) == 0, node.Now())
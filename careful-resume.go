@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"time"
+)
+
+// PathState is the path state saved from a prior connection over the same
+// path, which is the input to Careful Resume.
+type PathState struct {
+	Cwnd Bytes // saved_cwnd, the capacity in bytes
+	RTT  Clock // saved_rtt, the minimum RTT
+}
+
+// crPhase is the Careful Resume phase.
+type crPhase int
+
+const (
+	crReconnaissance crPhase = iota
+	crUnvalidated
+	crValidating
+	crNormal
+)
+
+// String implements fmt.Stringer.
+func (p crPhase) String() string {
+	switch p {
+	case crReconnaissance:
+		return "Reconnaissance"
+	case crUnvalidated:
+		return "Unvalidated"
+	case crValidating:
+		return "Validating"
+	case crNormal:
+		return "Normal"
+	}
+	return "Unknown"
+}
+
+// CarefulResume implements slow-start with Careful Resume, according to
+// draft-ietf-tsvwg-careful-resume.  In the Reconnaissance phase, standard
+// slow-start is used while the current RTT is checked against the saved RTT.
+// If confirmed, the Unvalidated phase jumps cwnd to CRJumpFactor of the saved
+// cwnd, paced over the current RTT.  When the first packet sent in the jump is
+// acknowledged, the Validating phase reduces cwnd to the flight size, and
+// continues with standard slow-start until the last packet sent in the jump is
+// acknowledged.  A CE mark in the Unvalidated or Validating phases results in
+// Safe Retreat, which sets cwnd to half the validated pipe size and exits
+// slow-start immediately, rather than waiting for the jump to be acknowledged.
+// An SCE mark in the Unvalidated phase moves to the Validating phase early.
+// In the Normal phase, standard slow-start is used.
+//
+// https://datatracker.ietf.org/doc/draft-ietf-tsvwg-careful-resume/
+type CarefulResume struct {
+	saved     PathState
+	phase     crPhase
+	firstJump Seq   // first sequence number sent in the Unvalidated phase
+	lastJump  Seq   // SND.NXT on entering the Validating phase
+	pipeSize  Bytes // PS, the validated pipe size
+	pipeSeq   Seq   // ACKed sequence number for pipe size accounting
+	ss        *StdSS
+}
+
+// NewCarefulResume returns a new CarefulResume, with the given saved path
+// state.
+func NewCarefulResume(saved PathState) *CarefulResume {
+	return &CarefulResume{
+		saved,                  // saved
+		crReconnaissance,       // phase
+		0,                      // firstJump
+		0,                      // lastJump
+		0,                      // pipeSize
+		0,                      // pipeSeq
+		NewStdSS(DefaultStdSS), // ss
+	}
+}
+
+// init implements initer.
+func (c *CarefulResume) init(flow *Flow, node Node) {
+	node.Logf("flow:%d careful resume saved cwnd:%d rtt:%sms",
+		flow.id, c.saved.Cwnd, c.saved.RTT.StringMS())
+}
+
+// setPhase sets the phase and logs the transition.
+func (c *CarefulResume) setPhase(phase crPhase, flow *Flow, node Node) {
+	node.Logf("flow:%d careful resume %s->%s cwnd:%d in-flight:%d ps:%d",
+		flow.id, c.phase, phase, flow.cwnd, flow.inFlight, c.pipeSize)
+	flow.traceStage(c.phase, phase, node)
+	c.phase = phase
+}
+
+// confirmed returns true if the current RTT is consistent with the saved RTT.
+func (c *CarefulResume) confirmed(flow *Flow) bool {
+	if c.saved.Cwnd <= IWSegs*flow.mss() || c.saved.RTT == 0 || flow.srtt == 0 {
+		return false
+	}
+	return flow.srtt >= Clock(float64(c.saved.RTT)*CRMinRTTFactor) &&
+		flow.srtt <= Clock(float64(c.saved.RTT)*CRMaxRTTFactor)
+}
+
+// jump enters the Unvalidated phase, setting cwnd to the jump cwnd, and the
+// pacing rate to send it over the current RTT.
+func (c *CarefulResume) jump(flow *Flow, node Node) {
+	j := Bytes(float64(c.saved.Cwnd) * CRJumpFactor)
+	if j <= flow.cwnd {
+		c.setPhase(crNormal, flow, node)
+		return
+	}
+	c.firstJump = flow.seq
+	c.pipeSize = flow.inFlight
+	c.pipeSeq = flow.receiveNext
+	c.setPhase(crUnvalidated, flow, node)
+	flow.setCWND(j, node)
+	flow.pacingRate = CalcBitrate(j, time.Duration(flow.srtt))
+}
+
+// validate enters the Validating phase, reducing cwnd to the flight size.
+func (c *CarefulResume) validate(flow *Flow, node Node) {
+	c.lastJump = flow.seq
+	c.setPhase(crValidating, flow, node)
+	flow.disableExplicitPacing()
+	if flow.inFlight < flow.cwnd {
+		flow.setCWND(max(flow.inFlight, c.pipeSize), node)
+	}
+}
+
+// updatePipeSize adds any newly acknowledged bytes to the pipe size.
+func (c *CarefulResume) updatePipeSize(flow *Flow) {
+	if flow.receiveNext > c.pipeSeq {
+		c.pipeSize += Bytes(flow.receiveNext - c.pipeSeq)
+		c.pipeSeq = flow.receiveNext
+	}
+}
+
+// handleCE implements handleCESSer.
+func (c *CarefulResume) handleCE(flow *Flow, node Node) (exit bool) {
+	switch c.phase {
+	case crUnvalidated, crValidating:
+		c.updatePipeSize(flow)
+		node.Logf("flow:%d careful resume safe retreat ps:%d", flow.id,
+			c.pipeSize)
+		flow.traceStage(c.phase, "SafeRetreat", node)
+		flow.disableExplicitPacing()
+		flow.setCWND(c.pipeSize/2, node)
+		exit = true
+	default:
+		exit = c.ss.handleCE(flow, node)
+	}
+	return
+}
+
+// handleSCE implements handleSCESSer.
+func (c *CarefulResume) handleSCE(flow *Flow, node Node) (exit bool) {
+	switch c.phase {
+	case crUnvalidated:
+		c.updatePipeSize(flow)
+		c.validate(flow, node)
+	case crValidating:
+		c.updatePipeSize(flow)
+	default:
+		exit = c.ss.handleSCE(flow, node)
+	}
+	return
+}
+
+// grow implements SlowStart.
+func (c *CarefulResume) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
+	switch c.phase {
+	case crReconnaissance:
+		if c.confirmed(flow) {
+			c.jump(flow, node)
+			return
+		}
+		c.setPhase(crNormal, flow, node)
+	case crUnvalidated:
+		c.updatePipeSize(flow)
+		if flow.receiveNext > c.firstJump {
+			c.validate(flow, node)
+		}
+		return
+	case crValidating:
+		c.updatePipeSize(flow)
+		if flow.receiveNext >= c.lastJump {
+			c.setPhase(crNormal, flow, node)
+		}
+	}
+	exit = c.ss.grow(acked, flow, node)
+	return
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+// Package cc defines the exported congestion control API for scim, so that
+// CCAs, slow-start algorithms and Responders may be kept in external modules.
+// Implementations are wrapped with NewPluginCCA, NewPluginSlowStart or
+// NewPluginResponder in the flow definitions, either in config.go, or in a main
+// package in another module that sets scim.Flows and calls scim.Main, for
+// example:
+//
+//	scim.Flows = []scim.Flow{
+//		scim.NewFlow(0, scim.ECN, scim.SCE,
+//			scim.NewPluginSlowStart(ours.NewSS()), scim.NoResponse{},
+//			scim.NewPluginCCA(ours.NewCCA()), scim.Pacing, true),
+//	}
+//	scim.Main()
+//
+// Byte counts are int64, times are time.Duration since the start of the
+// simulation, and rates are in bits per second.
+package cc
+
+import (
+	"time"
+)
+
+// FlowState is a read-only view of a flow's state.
+type FlowState interface {
+	// ID returns the flow ID.
+	ID() int
+	// Now returns the current simulation time.
+	Now() time.Duration
+	// MSS returns the flow's maximum segment size.
+	MSS() int64
+	// Cwnd returns the congestion window.
+	Cwnd() int64
+	// InFlight returns the number of bytes in flight.
+	InFlight() int64
+	// RTT returns the latest RTT sample.
+	RTT() time.Duration
+	// SRTT returns the smoothed RTT.
+	SRTT() time.Duration
+	// MinRTT returns the minimum RTT seen.
+	MinRTT() time.Duration
+	// MaxRTT returns the maximum RTT seen.
+	MaxRTT() time.Duration
+	// PacingRate returns the pacing rate, either as set explicitly, or
+	// calculated from cwnd and SRTT.
+	PacingRate() int64
+	// Seq returns the next sequence number to be sent (SND.NXT).
+	Seq() int64
+	// ACKed returns the highest cumulatively acknowledged sequence number.
+	ACKed() int64
+	// SignalNext returns the sequence number that must be acknowledged
+	// before the next congestion signal should be responded to.
+	SignalNext() int64
+	// Alpha returns the fraction of CE marked bytes, as in DCTCP.
+	Alpha() float64
+	// RateSample returns the latest delivery rate sample.
+	RateSample() RateSample
+}
+
+// Flow is a FlowState that also allows a CCA or slow-start algorithm to
+// control the flow.
+type Flow interface {
+	FlowState
+	// SetCwnd sets the congestion window, which is clamped to a minimum of
+	// two segments.
+	SetCwnd(cwnd int64)
+	// SetPacingRate sets an explicit pacing rate, or with 0, restores
+	// pacing calculated from cwnd and SRTT.
+	SetPacingRate(rate int64)
+	// EndSignalWindow sets SignalNext to Seq, so congestion signals may be
+	// ignored until the data sent so far is acknowledged.
+	EndSignalWindow()
+	// Logf logs a message.
+	Logf(format string, a ...any)
+}
+
+// RateSample is a delivery rate sample, taken on each ACK according to
+// draft-cheng-iccrg-delivery-rate-estimation.
+type RateSample struct {
+	DeliveryRate int64 // bits per second, or 0 if invalid
+	Delivered    int64 // bytes delivered over the interval
+	DeliveredCE  int64 // CE marked bytes delivered over the interval
+	Interval     time.Duration
+	RTT          time.Duration // 0 if the ACK was delayed
+	AppLimited   bool
+}
+
+// CCA is a congestion control algorithm for congestion avoidance.  Grow is
+// called for each ACK that carries no congestion signal.  CCAs may also
+// implement CEHandler, SCEHandler, RTTUpdater, RateSampleHandler or
+// SlowStartExiter as necessary.
+type CCA interface {
+	Grow(acked int64, flow Flow)
+}
+
+// CEHandler handles CE marks.
+type CEHandler interface {
+	HandleCE(flow Flow)
+}
+
+// SCEHandler handles SCE marks.
+type SCEHandler interface {
+	HandleSCE(flow Flow)
+}
+
+// RTTUpdater receives each RTT sample.
+type RTTUpdater interface {
+	UpdateRTT(rtt time.Duration, flow Flow)
+}
+
+// RateSampleHandler receives the delivery rate sample taken on each ACK.
+type RateSampleHandler interface {
+	HandleRateSample(rs RateSample, flow Flow)
+}
+
+// SlowStartExiter takes some action on slow-start exit.
+type SlowStartExiter interface {
+	SlowStartExit(flow Flow)
+}
+
+// SlowStart is a slow-start algorithm.  Grow is called for every ACK in
+// slow-start, including those with CE or SCE feedback, after the signal is
+// passed to any SlowStartCEHandler or SlowStartSCEHandler, and returns true to
+// exit slow-start.  If a handler exits slow-start, Grow isn't called.
+// SlowStarts may also implement Initer, RTTUpdater, SlowStartCEHandler,
+// SlowStartSCEHandler or SlowStartRateSampleHandler as necessary.
+type SlowStart interface {
+	Grow(acked int64, flow Flow) (exit bool)
+}
+
+// Initer initializes a SlowStart when the connection is established.
+type Initer interface {
+	Init(flow Flow)
+}
+
+// SlowStartCEHandler handles CE marks, and returns true to exit slow-start.
+type SlowStartCEHandler interface {
+	HandleCE(flow Flow) (exit bool)
+}
+
+// SlowStartSCEHandler handles SCE marks, and returns true to exit slow-start.
+type SlowStartSCEHandler interface {
+	HandleSCE(flow Flow) (exit bool)
+}
+
+// SlowStartRateSampleHandler receives the delivery rate sample taken on each
+// ACK, and returns true to exit slow-start.
+type SlowStartRateSampleHandler interface {
+	HandleRateSample(rs RateSample, flow Flow) (exit bool)
+}
+
+// Responder returns a new cwnd in response to a congestion signal or event,
+// such as slow-start exit.
+type Responder interface {
+	Respond(flow FlowState) (cwnd int64)
+}
@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"fmt"
@@ -10,7 +10,8 @@ import (
 )
 
 // A CCA implements a congestion control algorithm. CCA implementations may also
-// implement handleCEer, handleSCEer or slowStartExiter as necessary.
+// implement handleCEer, handleSCEer, handleTelemetryer, handleRateSampler or
+// slowStartExiter as necessary.
 type CCA interface {
 	grow(Bytes, Packet, *Flow, Node)
 }
@@ -30,31 +31,52 @@ type handleTelemetryer interface {
 	handleTelemetry(Telemetry, *Flow, Node)
 }
 
+// A handleRateSampler can handle the delivery rate sample taken on each ACK.
+type handleRateSampler interface {
+	handleRateSample(rateSample, *Flow, Node)
+}
+
 // A slowStartExiter can take some action on slow-start exit.
 type slowStartExiter interface {
 	slowStartExit(*Flow, Node)
 }
 
+// A ceMDer has a multiplicative decrease on CE, which slow-starts may use.
+type ceMDer interface {
+	ceMD() float64
+}
+
+// ceMD returns the multiplicative decrease on CE for the flow's CCA, or CEMD
+// if it doesn't have one.
+func (f *Flow) ceMD() float64 {
+	if m, ok := f.cca.(ceMDer); ok {
+		return m.ceMD()
+	}
+	return CEMD
+}
+
 // Reno implements TCP Reno.
 type Reno struct {
+	MDScaling
 	sce         Responder
 	priorGrowth Clock
 	sceHistory  *clockRing
 }
 
 // NewReno returns a new Reno (not a NewReno :).
-func NewReno(sce Responder) *Reno {
+func NewReno(sce Responder, mds MDScaling) *Reno {
 	return &Reno{
-		sce,               // sce
-		0,                 // priorGrowth
-		newClockRing(Tau), // sceHistory
+		mds,                    // MDScaling
+		sceResponder(sce, mds), // sce
+		0,                      // priorGrowth
+		newClockRing(mds.Tau),  // sceHistory
 	}
 }
 
 // handleCE implements handleCEer.
 func (r *Reno) handleCE(flow *Flow, node Node) {
 	if flow.receiveNext > flow.signalNext {
-		flow.setCWND(Bytes(float64(flow.cwnd)*CEMD), node)
+		flow.setCWND(Bytes(float64(flow.cwnd)*r.CEMD), node)
 		flow.signalNext = flow.seq
 	}
 }
@@ -74,7 +96,7 @@ func (r *Reno) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 	}
 
 	if node.Now()-r.priorGrowth > flow.srtt { // time-based growth
-		flow.setCWND(flow.cwnd+MSS, node)
+		flow.setCWND(flow.cwnd+flow.mss(), node)
 		r.priorGrowth = node.Now()
 	}
 }
@@ -82,6 +104,7 @@ func (r *Reno) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 // Reno2 implements an experimental version of Reno that should be nearly
 // equivalent to Reno, but grows smoothly based on time.
 type Reno2 struct {
+	MDScaling
 	sce        Responder
 	growPrior  Clock
 	growTimer  Clock
@@ -89,12 +112,13 @@ type Reno2 struct {
 }
 
 // NewReno2 returns a new Reno2.
-func NewReno2(sce Responder) *Reno2 {
+func NewReno2(sce Responder, mds MDScaling) *Reno2 {
 	return &Reno2{
-		sce,               // sce
-		0,                 // growPrior
-		0,                 // growTimer
-		newClockRing(Tau), // sceHistory
+		mds,                    // MDScaling
+		sceResponder(sce, mds), // sce
+		0,                      // growPrior
+		0,                      // growTimer
+		newClockRing(mds.Tau),  // sceHistory
 	}
 }
 
@@ -106,7 +130,7 @@ func (r *Reno2) slowStartExit(flow *Flow, node Node) {
 // handleCE implements handleCEer.
 func (r *Reno2) handleCE(flow *Flow, node Node) {
 	if flow.receiveNext > flow.signalNext {
-		flow.setCWND(Bytes(float64(flow.cwnd)*CEMD), node)
+		flow.setCWND(Bytes(float64(flow.cwnd)*r.CEMD), node)
 		flow.signalNext = flow.seq
 	}
 }
@@ -116,8 +140,6 @@ func (r *Reno2) handleSCE(flow *Flow, node Node) {
 	if r.sceHistory.add(node.Now(), node.Now()-flow.srtt) &&
 		flow.receiveNext > flow.signalNext {
 		flow.setCWND(r.sce.Respond(flow, node), node)
-	} else {
-		//node.Logf("ignore SCE")
 	}
 }
 
@@ -125,16 +147,27 @@ func (r *Reno2) handleSCE(flow *Flow, node Node) {
 func (r *Reno2) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 	if !pkt.ECE && !pkt.ESCE {
 		r.growTimer += node.Now() - r.growPrior
-		for r.growTimer >= flow.srtt/Clock(MSS) {
+		for r.growTimer >= flow.srtt/Clock(flow.mss()) {
 			flow.setCWND(flow.cwnd+1, node)
-			r.growTimer -= flow.srtt / Clock(MSS)
+			r.growTimer -= flow.srtt / Clock(flow.mss())
 		}
 	}
 	r.growPrior = node.Now()
 }
 
+// ScalableOptions contains the options for Scalable.  DefaultScalable
+// contains the defaults.
+type ScalableOptions struct {
+	CEMD       float64 // MD on CE
+	Tau        int     // SCE-MD scale factor
+	Alpha      Bytes   // Scalable TCP 1/a
+	Lwnd       Bytes   // lwnd- max cwnd for Reno growth
+	RenoSmooth bool    // if true, use per-ACK Reno growth
+}
+
 // Scalable implements the Scalable TCP CCA.
 type Scalable struct {
+	ScalableOptions
 	sce            Responder
 	growPrior      Clock
 	growOscillator Clock
@@ -143,13 +176,15 @@ type Scalable struct {
 }
 
 // NewScalable returns a new Scalable.
-func NewScalable(sce Responder) *Scalable {
+func NewScalable(sce Responder, opt ScalableOptions) *Scalable {
+	m := MDScaling{opt.Tau, opt.CEMD}
 	return &Scalable{
-		sce,               // sce
-		0,                 // priorGrowth
-		0,                 // growOscillator
-		0,                 // growRem
-		newClockRing(Tau), // sceHistory
+		opt,                   // ScalableOptions
+		sceResponder(sce, m),  // sce
+		0,                     // priorGrowth
+		0,                     // growOscillator
+		0,                     // growRem
+		newClockRing(opt.Tau), // sceHistory
 	}
 }
 
@@ -157,11 +192,16 @@ func NewScalable(sce Responder) *Scalable {
 func (s *Scalable) handleCE(flow *Flow, node Node) {
 	if flow.receiveNext > flow.signalNext {
 		c := flow.cwnd
-		flow.setCWND(Bytes(float64(c)*ScalableCEMD), node)
+		flow.setCWND(Bytes(float64(c)*s.CEMD), node)
 		flow.signalNext = flow.seq
 	}
 }
 
+// ceMD implements ceMDer.
+func (s *Scalable) ceMD() float64 {
+	return s.CEMD
+}
+
 // handleSCE implements handleSCEer.
 func (s *Scalable) handleSCE(flow *Flow, node Node) {
 	if s.sceHistory.add(node.Now(), node.Now()-flow.srtt) &&
@@ -177,21 +217,21 @@ func (s *Scalable) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 	}
 
 	// Reno-linear growth when below scalable cwnd threshold
-	if flow.cwnd < ScalableLwnd {
+	if flow.cwnd < s.Lwnd {
 		// smoother, time-based growth, if enabled
-		if ScalableRenoSmooth {
+		if s.RenoSmooth {
 			s.growOscillator += node.Now() - s.growPrior
 			var r Bytes
-			for s.growOscillator >= flow.srtt/Clock(MSS) {
+			for s.growOscillator >= flow.srtt/Clock(flow.mss()) {
 				r++
-				s.growOscillator -= flow.srtt / Clock(MSS)
+				s.growOscillator -= flow.srtt / Clock(flow.mss())
 			}
 			flow.setCWND(flow.cwnd+r, node)
 			return
 		}
 		// standard growth, one MSS per RTT
 		if node.Now()-s.growPrior > flow.srtt {
-			flow.setCWND(flow.cwnd+MSS, node)
+			flow.setCWND(flow.cwnd+flow.mss(), node)
 			s.growPrior = node.Now()
 		}
 		return
@@ -199,14 +239,24 @@ func (s *Scalable) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 
 	// Scalable growth
 	a := acked + s.growRem
-	g := a / ScalableAlpha
-	s.growRem = a % ScalableAlpha
+	g := a / s.Alpha
+	s.growRem = a % s.Alpha
 	flow.setCWND(flow.cwnd+g, node)
 	s.growPrior = node.Now()
 }
 
+// CUBICOptions contains the options for CUBIC.  DefaultCUBIC contains the
+// defaults.
+type CUBICOptions struct {
+	Beta            float64 // RFC 9438 Section 4.6
+	Tau             int     // SCE-MD scale factor
+	C               float64 // RFC 9438 Section 5
+	FastConvergence bool    // RFC 9438 Section 4.7
+}
+
 // CUBIC implements a basic version of RFC9438 CUBIC.
 type CUBIC struct {
+	CUBICOptions
 	sce        Responder
 	tEpoch     Clock
 	cwndEpoch  Bytes
@@ -216,17 +266,24 @@ type CUBIC struct {
 }
 
 // NewCUBIC returns a new CUBIC.
-func NewCUBIC(sce Responder) *CUBIC {
+func NewCUBIC(sce Responder, opt CUBICOptions) *CUBIC {
+	m := MDScaling{opt.Tau, opt.Beta}
 	return &CUBIC{
-		sce,               // sce
-		0,                 // tEpoch
-		0,                 // cwndEpoch
-		0,                 // wMax
-		0,                 // wEst
-		newClockRing(Tau), // sceHistory
+		opt,                   // CUBICOptions
+		sceResponder(sce, m),  // sce
+		0,                     // tEpoch
+		0,                     // cwndEpoch
+		0,                     // wMax
+		0,                     // wEst
+		newClockRing(opt.Tau), // sceHistory
 	}
 }
 
+// ceMD implements ceMDer.
+func (c *CUBIC) ceMD() float64 {
+	return c.Beta
+}
+
 // slowStartExit implements CCA.
 func (c *CUBIC) slowStartExit(flow *Flow, node Node) {
 	c.tEpoch = node.Now()
@@ -239,7 +296,7 @@ func (c *CUBIC) slowStartExit(flow *Flow, node Node) {
 func (c *CUBIC) handleCE(flow *Flow, node Node) {
 	if flow.receiveNext > flow.signalNext {
 		c.updateWmax(flow.cwnd)
-		flow.setCWND(Bytes(float64(flow.cwnd)*CubicBeta), node)
+		flow.setCWND(Bytes(float64(flow.cwnd)*c.Beta), node)
 		c.tEpoch = node.Now()
 		c.cwndEpoch = flow.cwnd
 		c.wEst = c.cwndEpoch
@@ -250,8 +307,8 @@ func (c *CUBIC) handleCE(flow *Flow, node Node) {
 // updateWmax updates CUBIC's wMax from the given cwnd, performing fast
 // convergence if enabled.
 func (c *CUBIC) updateWmax(cwnd Bytes) {
-	if CubicFastConvergence && cwnd < c.wMax {
-		c.wMax = Bytes(float64(cwnd) * ((1.0 + CubicBeta) / 2))
+	if c.FastConvergence && cwnd < c.wMax {
+		c.wMax = Bytes(float64(cwnd) * ((1.0 + c.Beta) / 2))
 	} else {
 		c.wMax = cwnd
 	}
@@ -272,16 +329,16 @@ func (c *CUBIC) handleSCE(flow *Flow, node Node) {
 // grow implements CCA.
 func (c *CUBIC) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 	t := node.Now() - c.tEpoch
-	u := c.wCubic(t)
-	e := c.updateWest(acked, flow.cwnd)
+	u := c.wCubic(t, flow.mss())
+	e := c.updateWest(acked, flow.cwnd, flow.mss())
 	//c0 := flow.cwnd
 	//node.Logf("t:%d u:%d e:%d beta:%f", t, u, e, c.beta)
 	if u < e { // Reno-friendly region
 		flow.setCWND(e, node)
 		//node.Logf("  friendly cwnd0:%d cwnd:%d", c0, flow.cwnd)
 	} else { // concave and convex regions
-		r := c.target(flow.cwnd, t+flow.srtt)
-		flow.setCWND(flow.cwnd+MSS*(r-flow.cwnd)/flow.cwnd, node)
+		r := c.target(flow.cwnd, t+flow.srtt, flow.mss())
+		flow.setCWND(flow.cwnd+flow.mss()*(r-flow.cwnd)/flow.cwnd, node)
 		/*
 			if flow.cwnd < c.wMax {
 				node.Logf("  concave cwnd:%d cwnd0:%d r:%d t:%d srtt:%d",
@@ -296,28 +353,28 @@ func (c *CUBIC) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 
 // updateWest updates and returns the value for wEst according to RFC9438
 // section 4.3, except in bytes instead of MSS-sized segments.
-func (c *CUBIC) updateWest(acked, cwnd Bytes) Bytes {
-	a := 3.0 * (1.0 - CubicBeta) / (1.0 + CubicBeta)
+func (c *CUBIC) updateWest(acked, cwnd, mss Bytes) Bytes {
+	a := 3.0 * (1.0 - c.Beta) / (1.0 + c.Beta)
 	// TODO set alpha to 1 according to end of section 4.3 in RFC, but this
 	// is connected with ssthresh and drop support
-	s := c.wEst.Segments() + a*(acked.Segments()/cwnd.Segments())
-	c.wEst = Bytes(float64(MSS) * s)
+	s := c.wEst.Segments(mss) + a*(acked.Segments(mss)/cwnd.Segments(mss))
+	c.wEst = Bytes(float64(mss) * s)
 	return c.wEst
 }
 
 // wCubic returns W_cubic(t) according to RFC9438, except in bytes instead of
 // MSS-sized segments.
-func (c *CUBIC) wCubic(t Clock) Bytes {
-	wmax := c.wMax.Segments()
-	cwep := c.cwndEpoch.Segments()
-	k := math.Cbrt((wmax - cwep) / CubicC)
-	wc := CubicC*math.Pow(t.Seconds()-k, 3) + wmax
-	return Bytes(float64(MSS) * wc)
+func (c *CUBIC) wCubic(t Clock, mss Bytes) Bytes {
+	wmax := c.wMax.Segments(mss)
+	cwep := c.cwndEpoch.Segments(mss)
+	k := math.Cbrt((wmax - cwep) / c.C)
+	wc := c.C*math.Pow(t.Seconds()-k, 3) + wmax
+	return Bytes(float64(mss) * wc)
 }
 
 // target returns the target cwnd after an RTT has elapsed.
-func (c *CUBIC) target(cwnd Bytes, t Clock) Bytes {
-	w := c.wCubic(t)
+func (c *CUBIC) target(cwnd Bytes, t Clock, mss Bytes) Bytes {
+	w := c.wCubic(t, mss)
 	if w < cwnd {
 		return cwnd
 	}
@@ -327,19 +384,36 @@ func (c *CUBIC) target(cwnd Bytes, t Clock) Bytes {
 	return w
 }
 
+// MasloOptions contains the options for Maslo.  DefaultMaslo contains the
+// defaults.
+type MasloOptions struct {
+	Beta               float64     // rate MD on CE
+	M                  float64     // steady-state marking frequency, in Hz
+	OrttAdjustment     bool        // if true, adjust oRTT on CE marks
+	ProbeThreshold     float64     // multiple of rate since last signal
+	CwndScaleFactor    float64     // scale factor for pacing rate to CWND
+	BandwidthProbing   bool        // if true, enable bandwidth probing
+	SCEMDApproximation bool        // if true, approximate SCE response
+	AdjustSafeRTT      bool        // if true, adjust RTT for K calculations
+	MinimumCwnd        Bytes       // minimum CWND
+	Probe              EsspOptions // options for ESSP on bandwidth probes
+}
+
 // Maslo implements the MASLO TCP CCA.
 type Maslo struct {
+	MasloOptions
 	stage             int
 	ortt              Clock
 	priorRateOnSignal Bitrate
 }
 
 // NewMaslo returns a new Maslo.
-func NewMaslo() *Maslo {
+func NewMaslo(opt MasloOptions) *Maslo {
 	return &Maslo{
-		-1, // stage
-		0,  // ortt
-		0,  // priorRateOnSignal
+		opt, // MasloOptions
+		-1,  // stage
+		0,   // ortt
+		0,   // priorRateOnSignal
 	}
 }
 
@@ -358,9 +432,9 @@ func (m *Maslo) slowStartExit(flow *Flow, node Node) {
 func (m *Maslo) handleCE(flow *Flow, node Node) {
 	m.priorRateOnSignal = flow.pacingRate
 	if flow.receiveNext > flow.signalNext {
-		flow.pacingRate = Bitrate(float64(flow.pacingRate) * MasloBeta)
-		if MasloOrttAdjustment {
-			m.ortt = Clock(float64(m.ortt) * MasloBeta)
+		flow.pacingRate = Bitrate(float64(flow.pacingRate) * m.Beta)
+		if m.OrttAdjustment {
+			m.ortt = Clock(float64(m.ortt) * m.Beta)
 		}
 		m.syncCWND(flow, node)
 		flow.signalNext = flow.seq
@@ -371,10 +445,10 @@ func (m *Maslo) handleCE(flow *Flow, node Node) {
 func (m *Maslo) handleSCE(flow *Flow, node Node) {
 	m.priorRateOnSignal = flow.pacingRate
 	//r0 := flow.pacingRate
-	if MasloSCEMDApproximation {
-		flow.pacingRate -= flow.pacingRate / Bitrate(m.k()*MasloM)
+	if m.SCEMDApproximation {
+		flow.pacingRate -= flow.pacingRate / Bitrate(float64(m.k())*m.M)
 	} else {
-		flow.pacingRate = Bitrate(float64(flow.pacingRate) * MasloSCEMD[m.stage])
+		flow.pacingRate = Bitrate(float64(flow.pacingRate) * m.sceMD())
 	}
 
 	// first attempt to tweak low-rate oscillations (too much):
@@ -410,16 +484,16 @@ func (m *Maslo) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 // startProbe starts a bandwidth probe by re-entering slow-start with ESSP.
 func (m *Maslo) startProbe(flow *Flow, node Node) (ok bool) {
 	// skip if probing not enabled or prior rate on signal uninitialized
-	if !MasloBandwidthProbing || m.priorRateOnSignal == 0 {
+	if !m.BandwidthProbing || m.priorRateOnSignal == 0 {
 		return
 	}
 	// skip if pacing rate hasn't reached threshold
-	t := Bitrate(float64(m.priorRateOnSignal) * MasloProbeThreshold)
+	t := Bitrate(float64(m.priorRateOnSignal) * m.ProbeThreshold)
 	if flow.pacingRate <= t {
 		return
 	}
 	// initiate probe with customized instance of ESSP
-	e := NewEssp()
+	e := NewEssp(m.Probe)
 	e.stage = 1
 	e.minRtt = flow.srtt
 	e.init(flow, node)
@@ -432,20 +506,21 @@ func (m *Maslo) startProbe(flow *Flow, node Node) (ok bool) {
 	c0 := flow.cwnd
 	// new version below scales CWND
 	k := Bytes(e.k())
-	flow.setCWND(Bytes(y*r)*(k-1)/k, node)
+	flow.setCWNDFor(causeProbe, Bytes(y*r)*(k-1)/k, node)
 	// old version below does not scale CWND
 	//flow.cwnd = Bytes(y * r)
 	flow.disableExplicitPacing()
 	node.Logf("flow:%d maslo probe rate:%.0f->%.0f rate(prior-signal):%.0f cwnd:%d->%d",
 		flow.id, r0.Bps(), flow.getPacingRate().Bps(), m.priorRateOnSignal.Bps(),
 		c0, flow.cwnd)
+	flow.traceProbe("bandwidth", node)
 	ok = true
 	return
 }
 
 // updateRtt implements updateRtter.
 func (m *Maslo) updateRtt(rtt Clock, flow *Flow, node Node) {
-	//r0 := flow.pacingRate
+	r0 := flow.pacingRate
 	// older version
 	//flow.pacingRate += Bitrate(float64(flow.pacingRate) *
 	//	time.Duration(m.ortt-flow.srtt).Seconds() /
@@ -455,14 +530,18 @@ func (m *Maslo) updateRtt(rtt Clock, flow *Flow, node Node) {
 	//	time.Duration(m.ortt-flow.srtt).Seconds() /
 	//	max(m.ortt, flow.srtt).Seconds())
 	// new version
-	p := flow.pacingRate.Yps() / float64(MSS)
+	p := flow.pacingRate.Yps() / float64(flow.mss())
 	flow.pacingRate += Bitrate(float64(flow.pacingRate) *
 		(time.Duration(m.ortt - flow.srtt).Seconds()) /
-		(1.0/MasloM + 1.0/p + max(m.ortt, flow.srtt).Seconds()))
+		(1.0/m.M + 1.0/p + max(m.ortt, flow.srtt).Seconds()))
 	m.syncCWND(flow, node)
-	//dr := time.Duration(m.ortt - flow.srtt).Seconds()
-	//node.Logf("ortt:%dns srtt:%dns ortt-srtt:%.9fs drate:%.0f bps",
-	//	m.ortt, flow.srtt, dr, flow.pacingRate.Bps()-r0.Bps())
+	if flow.tracing(TraceState) {
+		flow.traceState(node,
+			"ortt", m.ortt,
+			"srtt", flow.srtt,
+			"drate", flow.pacingRate.Bps()-r0.Bps(),
+			"stage", m.stage)
+	}
 	m.ortt = flow.srtt
 	m.adjustStage(flow, node)
 }
@@ -479,6 +558,7 @@ func (m *Maslo) setSafeStage(flow *Flow, node Node) {
 			LeoK[s],
 			time.Duration(flow.srtt).Milliseconds(),
 			time.Duration(r).Milliseconds())
+		flow.traceStage(m.stage, s, node)
 		m.stage = s
 	}
 	/*
@@ -515,6 +595,7 @@ func (m *Maslo) adjustStage(flow *Flow, node Node) {
 			LeoK[s],
 			time.Duration(flow.srtt).Milliseconds(),
 			time.Duration(r).Milliseconds())
+		flow.traceStage(m.stage, s, node)
 		m.stage = s
 	}
 	/*
@@ -543,9 +624,9 @@ func (m *Maslo) adjustStage(flow *Flow, node Node) {
 // stage.
 func (m *Maslo) safeStageRTT(flow *Flow) (rtt Clock) {
 	rtt = flow.srtt
-	if MasloAdjustSafeRTT {
-		if p := flow.pacingRate.Yps() / float64(MSS); p < MasloM {
-			rtt = Clock(float64(rtt) * 2 * MasloM / p)
+	if m.AdjustSafeRTT {
+		if p := flow.pacingRate.Yps() / float64(flow.mss()); p < m.M {
+			rtt = Clock(float64(rtt) * 2 * m.M / p)
 		}
 	}
 	return
@@ -609,9 +690,9 @@ func (m *Maslo) stageFloor(stage int) Clock {
 func (m *Maslo) syncCWND(flow *Flow, node Node) {
 	// new version
 	c := flow.cwndFromPacingRate()
-	c = Bytes(float64(c) * MasloCwndScaleFactor)
-	if c < MasloMinimumCwnd {
-		c = MasloMinimumCwnd
+	c = Bytes(float64(c) * m.CwndScaleFactor)
+	if c < m.MinimumCwnd {
+		c = m.MinimumCwnd
 	}
 	flow.setCWND(c, node)
 	// old version
@@ -622,6 +703,13 @@ func (m *Maslo) syncCWND(flow *Flow, node Node) {
 	//flow.setCWND(Bytes(2.0 * math.Sqrt(ka/ks) * c))
 }
 
+// sceMD returns the SCE MD for the current stage, such that M SCE marks
+// reduce the rate by the same factor that one round of growth increases it.
+func (m *Maslo) sceMD() float64 {
+	x := 1.0 + 1.0/float64(m.k())
+	return 1.0 / math.Pow(x, 1.0/m.M)
+}
+
 // k returns the current value of K.
 func (m *Maslo) k() (k int) {
 	switch {
@@ -634,7 +722,7 @@ func (m *Maslo) k() (k int) {
 	}
 	/*
 		// adjustment we tried without the RTT adjustment, didn't work out
-		if p := flow.pacingRate.Yps() / float64(MSS); p < MasloM {
+		if p := flow.pacingRate.Yps() / float64(flow.mss()); p < MasloM {
 			k = int(math.Round(float64(k) * MasloM / p))
 		}
 	*/
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math"
+)
+
+// PacedChirping implements a slow-start based on Paced Chirping, by Misund and
+// Briscoe.  Packets are sent in chirps of PCChirpLen packets, with inter-packet
+// gaps that decrease geometrically from PCGapSpread times the average gap to
+// the average gap divided by PCGapSpread, so each chirp briefly probes rates
+// above the average.  The average gap is the usual pacing gap from cwnd and
+// SRTT, and cwnd grows as in standard slow-start.
+//
+// Each ACK's RTT sample is given to all of the chirp packets it covers, so with
+// delayed ACKs, each sample stands for the packets up to the last one acked,
+// for which it's exact.  When all of a chirp's packets are acknowledged, the
+// samples for the last packet covered by each ACK are analyzed for the start of
+// an excursion, which is PCExcursionLen consecutive increases in queueing delay
+// that don't return to the starting delay before the end of the chirp, or an
+// SCE mark.  Excursions that end
+// before the chirp does are considered to be from competing traffic.  The send
+// gap at the start of the excursion estimates the bottleneck's packet service
+// time, so cwnd is set directly to the estimated BDP, and slow-start exits.
+// As queueing from competing traffic can make the estimate too low, cwnd is
+// never reduced on an estimate.  A CE mark exits with cwnd at the estimated BDP
+// if there is an estimate, or reduced by the CCA's CE MD otherwise.
+//
+// This is a simplified model of the algorithm, without the Linux
+// implementation's chirp scheduling and gain parameters, and requires pacing.
+//
+// https://arxiv.org/abs/1910.04464
+type PacedChirping struct {
+	chirp    []*chirp
+	sending  *chirp
+	gapRatio float64
+	estimate Clock // estimated packet service time, or 0 if none
+	acks     int   // number of RTT samples taken
+	sceCtr   int
+	ss       *StdSS
+}
+
+// chirp records the packets sent in a chirp.
+type chirp struct {
+	gap    Clock // average gap for the chirp
+	packet []chirpPacket
+}
+
+// chirpPacket records a packet sent in a chirp.
+type chirpPacket struct {
+	end  Seq   // sequence number after the packet
+	sent Clock // time sent
+	rtt  Clock // RTT sample, or 0 if none
+	ack  int   // number of the ACK with the RTT sample
+	sce  bool  // true if the ACK had ESCE set
+}
+
+// NewPacedChirping returns a new PacedChirping.
+func NewPacedChirping() *PacedChirping {
+	r := math.Pow(PCGapSpread, 2.0/float64(PCChirpLen-2))
+	return &PacedChirping{
+		nil,                    // chirp
+		nil,                    // sending
+		r,                      // gapRatio
+		0,                      // estimate
+		0,                      // acks
+		0,                      // sceCtr
+		NewStdSS(DefaultStdSS), // ss
+	}
+}
+
+// pace implements pacer.
+func (p *PacedChirping) pace(flow *Flow, node Node) (delay Clock) {
+	if p.sending == nil {
+		p.sending = &chirp{flow.pacingDelay(flow.mtu), nil}
+	}
+	c := p.sending
+	c.packet = append(c.packet, chirpPacket{flow.seq, node.Now(), 0, 0, false})
+	n := len(c.packet)
+	if n == PCChirpLen {
+		p.chirp = append(p.chirp, c)
+		p.sending = nil
+		return c.gap
+	}
+	// gap after packet i is gap * spread / ratio^(i-1), for i in 1..N-1
+	return Clock(float64(c.gap) * PCGapSpread / math.Pow(p.gapRatio,
+		float64(n-1)))
+}
+
+// packet returns the chirp packet ending at the given sequence number, or nil
+// if there isn't one.
+func (p *PacedChirping) packet(end Seq) *chirpPacket {
+	for _, c := range p.chirp {
+		for i := range c.packet {
+			if c.packet[i].end == end {
+				return &c.packet[i]
+			}
+		}
+	}
+	return nil
+}
+
+// updateRtt implements updateRtter.  The RTT sample is given to each chirp
+// packet covered by the cumulative ACK that doesn't already have one.
+func (p *PacedChirping) updateRtt(rtt Clock, flow *Flow, node Node) {
+	p.acks++
+	for _, c := range p.chirp {
+		c.sample(rtt, p.acks, flow.receiveNext)
+	}
+	if p.sending != nil {
+		p.sending.sample(rtt, p.acks, flow.receiveNext)
+	}
+}
+
+// sample gives the RTT sample from the given ACK to the packets ending at or
+// before the cumulative ACK that don't already have one.
+func (c *chirp) sample(rtt Clock, ack int, cumACK Seq) {
+	for i := range c.packet {
+		if k := &c.packet[i]; k.end <= cumACK && k.rtt == 0 {
+			k.rtt = rtt
+			k.ack = ack
+		}
+	}
+}
+
+// handleCE implements handleCESSer.
+func (p *PacedChirping) handleCE(flow *Flow, node Node) (exit bool) {
+	if p.estimate > 0 {
+		flow.setCWND(p.bdp(flow), node)
+	} else {
+		flow.setCWND(Bytes(float64(flow.cwnd)*flow.ceMD()), node)
+	}
+	exit = true
+	return
+}
+
+// handleSCE implements handleSCESSer.
+func (p *PacedChirping) handleSCE(flow *Flow, node Node) (exit bool) {
+	if k := p.packet(flow.receiveNext); k != nil {
+		k.sce = true
+	}
+	p.sceCtr++
+	exit = p.sceCtr >= p.ss.ExitThreshold
+	return
+}
+
+// grow implements SlowStart.
+func (p *PacedChirping) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
+	for len(p.chirp) > 0 {
+		c := p.chirp[0]
+		if flow.receiveNext < c.packet[len(c.packet)-1].end {
+			break
+		}
+		p.chirp = p.chirp[1:]
+		if g := p.analyze(c, flow, node); g > 0 {
+			p.estimate = g
+			c0 := flow.cwnd
+			flow.setCWND(max(p.bdp(flow), c0), node)
+			node.Logf("flow:%d chirp estimate gap:%sms cwnd:%d->%d",
+				flow.id, g.StringMS(), c0, flow.cwnd)
+			exit = true
+			return
+		}
+	}
+	exit = p.ss.grow(acked, flow, node)
+	return
+}
+
+// analyze returns the estimated packet service time from a chirp, or 0 if no
+// excursion was found.  Only the last packet covered by each ACK is analyzed,
+// and the gap is the average send gap over the packets before the excursion.
+func (p *PacedChirping) analyze(c *chirp, flow *Flow, node Node) (gap Clock) {
+	k := c.packet
+	var s []int // indexes of the last packet covered by each ACK
+	for i := range k {
+		if k[i].rtt != 0 && (i == len(k)-1 || k[i+1].ack != k[i].ack) {
+			s = append(s, i)
+		}
+	}
+	for j := 2; j < len(s); j++ {
+		i, h := s[j], s[j-2]
+		if k[i].sce || p.excursion(k, s[j-1:]) {
+			gap = (k[i].sent - k[h].sent) / Clock(i-h)
+			flow.traceState(node, "chirp_start", k[0].sent,
+				"excursion", i, "gap", gap)
+			return
+		}
+	}
+	return
+}
+
+// excursion returns true if the queueing delay of the chirp packets at the
+// given indexes increases for at least PCExcursionLen consecutive samples after
+// the first, and stays above the first sample's delay through the end of the
+// chirp.  RTT samples are used directly, as the min RTT is constant.
+func (p *PacedChirping) excursion(k []chirpPacket, s []int) bool {
+	if len(s) <= PCExcursionLen {
+		return false
+	}
+	for j := 1; j <= PCExcursionLen; j++ {
+		if k[s[j]].rtt <= k[s[j-1]].rtt {
+			return false
+		}
+	}
+	for j := PCExcursionLen + 1; j < len(s); j++ {
+		if k[s[j]].rtt <= k[s[0]].rtt {
+			return false
+		}
+	}
+	return true
+}
+
+// bdp returns the BDP from the estimated packet service time and the min RTT.
+func (p *PacedChirping) bdp(flow *Flow) Bytes {
+	return flow.mss() * Bytes(flow.minRtt) / Bytes(p.estimate)
+}
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+// Command scim runs the simulator with the settings in config.go.
+package main
+
+import (
+	"github.com/heistp/scim"
+)
+
+func main() {
+	scim.Main()
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math"
+)
+
+// Codel implements CoDel (RFC 8289), with separate CoDel instances for SCE and
+// CE.  The SCE instance is normally configured with a lower target than the CE
+// instance, and may be disabled with a target of 0.
+type Codel struct {
+	queue  []Packet
+	length Bytes
+	maxLen Bytes // largest packet length seen
+	mode   ECNMode
+	// CoDel instances
+	sce codel
+	ce  codel
+	// Plots
+	*aqmPlot
+}
+
+// NewCodel returns a new Codel.  For plain CoDel, use an sceTarget of 0.
+func NewCodel(sceTarget, ceTarget, interval Clock, mode ECNMode) *Codel {
+	p := newAqmPlot()
+	return &Codel{
+		make([]Packet, 0),                  // queue
+		0,                                  // length
+		0,                                  // maxLen
+		mode,                               // mode
+		newCodel(sceTarget, interval, nil), // sce
+		newCodel(ceTarget, interval, p),    // ce
+		p,                                  // aqmPlot
+	}
+}
+
+// NewFQCodel returns a new FQ-CoDel (RFC 8290), which is an FQ with a Codel
+// for each sub-queue, and a quantum of the largest packet length seen.
+func NewFQCodel(sceTarget, ceTarget, interval Clock, mode ECNMode) *FQ {
+	return NewFQ(1024, 0, func() AQM {
+		return NewCodel(sceTarget, ceTarget, interval, mode)
+	})
+}
+
+// Start implements Starter.
+func (c *Codel) Start(node Node) error {
+	return c.aqmPlot.Start(node)
+}
+
+// Enqueue implements AQM.
+func (c *Codel) Enqueue(pkt Packet, node Node) {
+	pkt.Enqueue = node.Now()
+	c.queue = append(c.queue, pkt)
+	c.length += pkt.Len
+	c.maxLen = max(c.maxLen, pkt.Len)
+	c.plotLength(len(c.queue), node.Now())
+}
+
+// Dequeue implements AQM.
+func (c *Codel) Dequeue(node Node) (pkt Packet, ok bool) {
+	if len(c.queue) == 0 {
+		return
+	}
+	// pop from head
+	pkt, c.queue = c.queue[0], c.queue[1:]
+	c.length -= pkt.Len
+
+	// run codel
+	s := node.Now() - pkt.Enqueue
+	sce := c.sce.control(s, c.length, c.maxLen, node)
+	ce := c.ce.control(s, c.length, c.maxLen, node)
+
+	ok = true
+	var m mark
+	if ce {
+		m = c.mode.apply(markCE, &pkt)
+	} else if sce {
+		m = c.mode.apply(markSCE, &pkt)
+	}
+
+	c.plotSojourn(s, len(c.queue) == 0, node.Now())
+	c.plotLength(len(c.queue), node.Now())
+	c.plotMark(m, node.Now())
+
+	return
+}
+
+// Stop implements Stopper.
+func (c *Codel) Stop(node Node) error {
+	return c.aqmPlot.Stop(node)
+}
+
+// Peek implements AQM.
+func (c *Codel) Peek(node Node) (pkt Packet, ok bool) {
+	if len(c.queue) == 0 {
+		return
+	}
+	ok = true
+	pkt = c.queue[0]
+	return
+}
+
+// Len implements AQM.
+func (c *Codel) Len() int {
+	return len(c.queue)
+}
+
+// codel is the core implementation of the CoDel algorithm.  Since drops are
+// not yet supported by the sender, at most one packet is marked per dequeue, as
+// in the Linux implementation when ECN is enabled.
+type codel struct {
+	// parameters
+	target   Clock
+	interval Clock
+	// variables
+	firstAboveTime Clock
+	dropNext       Clock
+	count          int
+	lastCount      int
+	dropping       bool
+	// for plotting
+	*aqmPlot
+}
+
+// newCodel returns a new codel.
+func newCodel(target, interval Clock, plot *aqmPlot) codel {
+	return codel{
+		target,   // target
+		interval, // interval
+		0,        // firstAboveTime
+		0,        // dropNext
+		0,        // count
+		0,        // lastCount
+		false,    // dropping
+		plot,     // aqmPlot
+	}
+}
+
+// control runs CoDel for a packet with the given sojourn time and remaining
+// queue length, and the largest packet length seen, and returns true if a mark
+// is indicated.  False is always returned if the target is 0.
+func (c *codel) control(sojourn Clock, length, maxLen Bytes,
+	node Node) (mark bool) {
+	if c.target == 0 {
+		return
+	}
+	now := node.Now()
+	ok := c.okToMark(sojourn, length, maxLen, now)
+	if c.dropping {
+		if !ok {
+			c.dropping = false
+		} else if now >= c.dropNext {
+			mark = true
+			c.count++
+			c.dropNext = c.controlLaw(c.dropNext)
+		}
+	} else if ok {
+		mark = true
+		c.dropping = true
+		d := c.count - c.lastCount
+		c.count = 1
+		if d > 1 && now-c.dropNext < 16*c.interval {
+			c.count = d
+		}
+		c.dropNext = c.controlLaw(now)
+		c.lastCount = c.count
+	}
+	// plot the sojourn time error as delta, and the mark interval as sigma
+	if c.aqmPlot != nil {
+		var i Clock
+		if c.dropping {
+			i = c.markInterval()
+		}
+		c.plotDeltaSigma(sojourn-c.target, i, now)
+	}
+	return
+}
+
+// okToMark returns true if the sojourn time has been above target for at least
+// an interval, and more than a packet of the largest length seen remains in the
+// queue, as with maxpacket in Linux.
+func (c *codel) okToMark(sojourn Clock, length, maxLen Bytes,
+	now Clock) bool {
+	if sojourn < c.target || length <= maxLen {
+		c.firstAboveTime = 0
+		return false
+	}
+	if c.firstAboveTime == 0 {
+		c.firstAboveTime = now + c.interval
+		return false
+	}
+	return now >= c.firstAboveTime
+}
+
+// controlLaw returns the time of the next mark after the given time.
+func (c *codel) controlLaw(t Clock) Clock {
+	return t + c.markInterval()
+}
+
+// markInterval returns the interval between marks for the current count.
+func (c *codel) markInterval() Clock {
+	return Clock(float64(c.interval) / math.Sqrt(float64(c.count)))
+}
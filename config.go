@@ -1,11 +1,12 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
-	"math"
 	"time"
+
+	"github.com/heistp/scim/sim"
 )
 
 ////////////////
@@ -19,7 +20,11 @@ const Duration = 10 * time.Second
 // Sender: flows and path delay
 //
 // Configure the flows below (Flows), including per-flow schedules
-// (FlowSchedule), and per-flow path round-trip times (FlowDelay).
+// (FlowSchedule), per-flow path round-trip times (FlowDelay), and per-flow
+// MTUs (FlowMTU).  Flows not listed in FlowMTU, or with an MTU of 0, use the
+// default MTU.  Smaller MTUs approximate small-packet flows like VoIP or
+// gaming, and larger ones jumbo frames.  See AQMByteMode for how marks are
+// scaled to packet length.
 //
 // The shipped default includes a single Reno-SCE flow that uses standard
 // slow-start, with base reduction on SCE (see DefaultSSBaseReduction) and
@@ -27,12 +32,30 @@ const Duration = 10 * time.Second
 var (
 	Flows = []Flow{
 		AddFlow(NoECN, NoSCE, NoSS{}, NoResponse{}, NewStuttgart(), Pacing, true),
-		//AddFlow(ECN, SCE, NewStdSS(), TargetCWND{}, NewReno(RMD), Pacing, true),
-		//AddFlow(ECN, SCE, NewEssp(), NoResponse{}, NewReno(RMD), Pacing, true),
-		//AddFlow(ECN, SCE, NewEssp(), NoResponse{}, NewReno2(RMD), Pacing, true),
-		//AddFlow(ECN, SCE, NewEssp(), NoResponse{}, NewCUBIC(CMD), Pacing, true),
-		//AddFlow(ECN, SCE, NewEssp(), NoResponse{}, NewScalable(SMD), Pacing, true),
-		//AddFlow(ECN, SCE, NewEssp(), NoResponse{}, NewMaslo(), Pacing, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), TargetCWND{}, NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), RateBDP{}, NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), NoResponse{}, NewReno(RMD, R16), Pacing, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), NoResponse{}, NewReno(RMD, DefaultMDScaling), PacingLinux, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), NewScriptResponder("scripts/bdp.lua"), NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(DefaultEssp), NoResponse{}, NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(EsspNoDelay), NoResponse{}, NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(DefaultEssp), NoResponse{}, NewReno2(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewPacedChirping(), NoResponse{}, NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewCarefulResume(PathState{250000, Clock(20 * time.Millisecond)}), NoResponse{}, NewReno(RMD, DefaultMDScaling), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(DefaultEssp), NoResponse{}, NewCUBIC(CMD, DefaultCUBIC), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(DefaultEssp), NoResponse{}, NewCUBIC(CMD, CUBICBeta08), Pacing, true),
+		//AddFlow(ECN, NoSCE, NewLinuxHyStart(), NoResponse{}, NewLinuxCUBIC(), Pacing, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), NoResponse{}, NewScriptCCA("scripts/reno-sce.lua"), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(DefaultEssp), NoResponse{}, NewScalable(SMD, DefaultScalable), Pacing, true),
+		//AddFlow(ECN, SCE, NewEssp(DefaultEssp), NoResponse{}, NewMaslo(DefaultMaslo), Pacing, true),
+		//AddFlow(ECN, NoSCE, NewStdSS(DefaultStdSS), AlphaMD{}, NewDCTCP(), Pacing, true),
+		//AddFlow(L4S, NoSCE, NewStdSS(DefaultStdSS), AlphaMD{}, NewPrague(), Pacing, true),
+		//AddFlow(ECN, NoSCE, NoSS{}, NoResponse{}, NewBBRv1(), Pacing, true),
+		//AddFlow(ECN, NoSCE, NoSS{}, NoResponse{}, NewBBRv3(), Pacing, true),
+		//AddFlow(ECN, NoSCE, NoSS{}, NoResponse{}, NewVegas(), Pacing, true),
+		//AddFlow(ECN, NoSCE, NoSS{}, NoResponse{}, NewFAST(), Pacing, true),
+		//AddFlow(ECN, NoSCE, NoSS{}, NoResponse{}, NewLEDBAT(), Pacing, true),
+		//AddFlow(ECN, SCE, NewStdSS(DefaultStdSS), NoResponse{}, NewCoupled(MPTCP, DefaultMDScaling), Pacing, true),
 	}
 	FlowSchedule = []FlowAt{
 		//FlowAt{1, Clock(10 * time.Second), true},
@@ -59,6 +82,11 @@ var (
 		Clock(20 * time.Millisecond),
 		Clock(20 * time.Millisecond),
 	}
+	FlowMTU = []Bytes{
+		//MTU,
+		//200,  // small packets
+		//9000, // jumbo frames
+	}
 )
 
 // Sender: default responses
@@ -66,22 +94,79 @@ var (
 // These standard responses are referenced from the Flow declarations.
 var (
 	// CUBIC-SCE response
-	CMD = MD(CubicBetaSCE)
-	CRF = RateFairMD{CubicBeta, Clock(20 * time.Millisecond)}
-	CHF = HybridFairMD{CubicBeta, Clock(20 * time.Millisecond)}
-	CMF = MildFairMD{CubicBeta, Clock(20 * time.Millisecond)}
+	CMD = ScaledMD{}
+	CRF = RateFairMD{CubicBeta, Tau, Clock(20 * time.Millisecond)}
+	CHF = HybridFairMD{CubicBeta, Tau, Clock(20 * time.Millisecond)}
+	CMF = MildFairMD{CubicBeta, Tau, Clock(20 * time.Millisecond)}
 
 	// Reno-SCE Response
-	RMD = MD(SCE_MD)
-	RRF = RateFairMD{CEMD, Clock(20 * time.Millisecond)}
-	RHF = HybridFairMD{CEMD, Clock(20 * time.Millisecond)}
-	RMF = MildFairMD{CEMD, Clock(20 * time.Millisecond)}
+	RMD = ScaledMD{}
+	RRF = RateFairMD{CEMD, Tau, Clock(20 * time.Millisecond)}
+	RHF = HybridFairMD{CEMD, Tau, Clock(20 * time.Millisecond)}
+	RMF = MildFairMD{CEMD, Tau, Clock(20 * time.Millisecond)}
+
+	// Reno MD-Scaling params with a Tau of 16 (use with NewReno(RMD, R16))
+	R16 = MDScaling{16, CEMD}
 
 	// Scalable Response
-	SMD = MD(SCE_MD) // MD(ScalableBetaSCE)
-	SRF = RateFairMD{ScalableCEMD, Clock(20 * time.Millisecond)}
-	SHF = HybridFairMD{ScalableCEMD, Clock(20 * time.Millisecond)}
-	SMF = MildFairMD{ScalableCEMD, Clock(20 * time.Millisecond)}
+	SMD = ScaledMD{}
+	SRF = RateFairMD{ScalableCEMD, Tau, Clock(20 * time.Millisecond)}
+	SHF = HybridFairMD{ScalableCEMD, Tau, Clock(20 * time.Millisecond)}
+	SMF = MildFairMD{ScalableCEMD, Tau, Clock(20 * time.Millisecond)}
+)
+
+// Sender: slow-start, CCA and pacing options
+//
+// Slow-start algorithms and CCAs with parameters take an options struct, with
+// the defaults in Default* (e.g. DefaultEssp, DefaultCUBIC) under Advanced
+// Settings, and Flows take PacingOptions, with the defaults in Pacing and
+// NoPacing.  Variants declared here may be given to some of the flows, to
+// compare settings within one run.
+var (
+	// ESSP without advancing the stage on delay
+	EsspNoDelay = EsspOptions{
+		EsspHalfKExit,     // HalfKExit
+		0,                 // DelayThreshold
+		EsspCWNDTargeting, // CWNDTargeting
+	}
+
+	// CUBIC with a beta of 0.8
+	CUBICBeta08 = CUBICOptions{
+		0.8,                  // Beta
+		Tau,                  // Tau
+		CubicC,               // C
+		CubicFastConvergence, // FastConvergence
+	}
+
+	// coupling for the subflows of a multipath connection, given to each
+	// subflow's NewCoupled (see UseMultipath to route them over distinct
+	// paths), with LIA, OLIA or BALIA
+	MPTCP = NewCoupling(LIA)
+
+	// pacing with Linux's default ratios, and TSO autosizing with the
+	// default min_tso_segs of 2
+	PacingLinux = PacingOptions{
+		true, // Enabled
+		2.0,  // SSRatio
+		1.2,  // CARatio
+		2,    // Burst
+		true, // TSOAutosize
+	}
+)
+
+// Sender: host qdisc
+//
+// UseHostQdisc is an optional queueing discipline on the sending host, between
+// the flows and the path, which sends at HostRate.  Use nil for none, so
+// packets go straight to the path.  With HostTSQ, each flow's bytes in the host
+// queue are limited to about 1ms at its pacing rate, as with Linux's TCP Small
+// Queues.  HostFQ paces flows itself, so flows that have pacing enabled don't.
+var (
+	UseHostQdisc HostQdisc = nil
+	//UseHostQdisc = NewHostFQ(2*MTU, 10*MTU, 100, 10000) // sch_fq defaults
+	//UseHostQdisc = NewPfifoFast(1000) // txqueuelen
+	HostRate = 1 * Gbps
+	HostTSQ  = true
 )
 
 ////////////////
@@ -108,41 +193,172 @@ var RateSchedule = []RateAt{
 //	}
 //}
 
+// Iface: ECT(1) interpretation for AQMs that take an ECNMode
+//
+// SCEMode uses ECT(1) as an SCE mark for SCE capable flows.  L4SMode treats
+// ECT(1) as the L4S identifier, and gives L4S flows the SCE signal as CE.
+var AQMECNMode = SCEMode
+
+// Iface: byte mode for DelTiC and Brickwall
+//
+// With AQMByteMode, marks are scaled to packet length (RFC 7141 byte-mode), so
+// a packet that a mark is indicated for is marked with a probability of its
+// length over the largest packet length seen.  Flows with small packets then
+// see marks in proportion to their bytes rather than their packets, while
+// packets of the largest length are marked as without it.
+var AQMByteMode = false
+
 // Iface: DelTiC AQM config
-//var UseAQM = NewDeltic(
+//var UseAQM AQM = NewDeltic(
 //	Clock(5*time.Millisecond),   // SCE
 //	Clock(25*time.Millisecond),  // CE
 //	Clock(125*time.Millisecond), // drop
+//	AQMECNMode,
 //)
 
 // Iface: DelTiC-MDS AQM config
-//var UseAQM = NewDelticMDS(Clock(5000 * time.Microsecond))
+//var UseAQM AQM = NewDelticMDS(Clock(5000*time.Microsecond), AQMECNMode)
 
 // Iface: DelTiM AQM config
-//var UseAQM = NewDeltim(Clock(5000 * time.Microsecond))
+//var UseAQM AQM = NewDeltim(Clock(5000*time.Microsecond), AQMECNMode)
 
 // Iface: DelTiM2 AQM config
-//var UseAQM = NewDeltim2(Clock(5*time.Millisecond), Clock(1*time.Millisecond))
+//var UseAQM AQM = NewDeltim2(Clock(5*time.Millisecond),
+//	Clock(1*time.Millisecond), AQMECNMode)
 
 // Iface: DelTiM common config
 var DeltimIdleWindow = Clock(5000 * time.Microsecond) // equal to burst
 
+// Iface: CoDel AQM config (use an SCE target of 0 for plain CoDel)
+//var UseAQM AQM = NewCodel(
+//	Clock(1*time.Millisecond),   // SCE target
+//	Clock(5*time.Millisecond),   // CE target
+//	Clock(100*time.Millisecond), // interval
+//	AQMECNMode,
+//)
+
+// Iface: FQ-CoDel AQM config (use an SCE target of 0 for plain FQ-CoDel)
+//var UseAQM AQM = NewFQCodel(
+//	Clock(1*time.Millisecond),   // SCE target
+//	Clock(5*time.Millisecond),   // CE target
+//	Clock(100*time.Millisecond), // interval
+//	AQMECNMode,
+//)
+
+// Iface: PIE AQM config
+//var UseAQM AQM = NewPIE(
+//	Clock(15*time.Millisecond), // target
+//	Clock(15*time.Millisecond), // update interval
+//)
+
+// Iface: PI2 AQM config
+//var UseAQM AQM = NewPI2(
+//	Clock(15*time.Millisecond), // target
+//	Clock(16*time.Millisecond), // update interval
+//)
+
+// Iface: DualPI2 AQM config
+//var UseAQM AQM = NewDualPI2(
+//	Clock(15*time.Millisecond), // target
+//	Clock(16*time.Millisecond), // update interval
+//	Clock(1*time.Millisecond),  // L queue step threshold
+//)
+
+// Iface: FQ config (flow queuing with DRR, and a new instance of the returned
+// AQM for each flow's sub-queue)
+//var UseAQM AQM = NewFQ(
+//	1024, // sub-queues
+//	0,    // quantum (0 for the largest packet length)
+//	func() AQM { return NewDeltim(Clock(5000*time.Microsecond), AQMECNMode) },
+//)
+
 // Iface: Brickwall AQM config
-//var UseAQM = NewBrickwall(
+//var UseAQM AQM = NewBrickwall(
 //	Clock(0*time.Millisecond),  // SCE
 //	Clock(12*time.Millisecond), // CE
 //	Clock(0*time.Millisecond),  // drop
+//	AQMECNMode,
 //)
 
 // Iface: Ramp AQM config
 var (
-	//UseAQM     = NewRamp()
+	//UseAQM AQM = NewRamp()
 	SCERampMin = Clock(TransferTime(RateInit, Bytes(MTU))) * 1
 	SCERampMax = Clock(100 * time.Millisecond)
 )
 
 // Iface: Telemetry config
-var UseAQM = NewTelemetryQueue()
+var UseAQM AQM = NewTelemetryQueue()
+
+////////////////
+//
+// Path Settings
+//
+// ECN rewriters are middleboxes that rewrite ECN state, and may be placed
+// before the bottleneck (UpstreamRewriter), after it (DownstreamRewriter), or
+// on the ACK path (ACKRewriter).  Use nil for none.  A tunnel may add
+// encapsulation overhead between the rewriters and the bottleneck.
+
+// Path: ECN rewriters (comment out the nil declaration to use an example)
+var (
+	UpstreamRewriter *ECNRewriter = nil
+	//UpstreamRewriter = NewECNRewriter(ClearECT, 1.0, 1) // bleach flow 1
+	DownstreamRewriter *ECNRewriter = nil
+	//DownstreamRewriter = NewECNRewriter(ClearSCE, 1.0) // strip SCE marks
+	ACKRewriter *ECNRewriter = nil
+	//ACKRewriter = NewECNRewriter(ClearESCE, 0.1) // strip 10% of ESCE
+)
+
+// Path: tunnel (comment out the nil declarations to use an example)
+//
+// TunnelEncap adds per-packet overhead before the bottleneck, and TunnelDecap
+// removes it after the path delay, so the bottleneck serializes the larger
+// packets, while the endpoints keep their own MSS.  Encapsulated packets over
+// the given link MTU are counted.  To model the reduced inner MTU of a real
+// deployment, set FlowMTU to the link MTU less the overhead (for WireGuard,
+// MTU - WireGuardOverhead).  Use nil for none.
+var (
+	TunnelEncap *Tunnel = nil
+	//TunnelEncap = NewTunnelEncap(WireGuardOverhead, MTU)
+	TunnelDecap *Tunnel = nil
+	//TunnelDecap = NewTunnelDecap(WireGuardOverhead)
+)
+
+// Path: reordering (comment out the nil declaration to use an example)
+//
+// UseReorderer reorders data packets after the path delay, either by swapping
+// packets with the next from the same flow, with a given probability, or by
+// sending them over multiple paths with different extra delays.  Use nil for
+// none.
+var (
+	UseReorderer *Reorderer = nil
+	//UseReorderer = NewReorderSwap(0.01) // swap 1% of packets
+	//UseReorderer = NewReorderMultipath([]Clock{0, Clock(1 * time.Millisecond)})
+)
+
+// Path: reordering params
+const ReorderMaxHold = Clock(1 * time.Millisecond) // max hold for a swap
+
+// Path: multipath (comment out the nil declaration to use an example)
+//
+// UseMultipath replaces the bottleneck and path delay with multiple paths, each
+// with its own Iface, AQM and delay, either per-flow (Delay) or for all flows
+// (FixedDelay), and sends each flow over the path given by its index in
+// FlowPath.  Flows not listed use path 0.  Plots from paths after the first
+// have the path in their names (e.g. sojourn.path1.xpl).  Use nil for a single
+// path.
+var (
+	UseMultipath *Multipath = nil
+	//UseMultipath = NewMultipath(FlowPath,
+	//	Path{NewIface(RateInit, RateSchedule, UseAQM), Delay(FlowDelay)},
+	//	Path{NewIface(50*Mbps, nil, NewDeltim(Clock(5000*time.Microsecond), AQMECNMode)),
+	//		FixedDelay(40 * time.Millisecond)},
+	//)
+	FlowPath = []int{
+		//0,
+		//1,
+	}
+)
 
 ////////////////
 //
@@ -170,7 +386,7 @@ const (
 	PlotSent         = false // sent.#.xpl
 	PlotSentInterval = Clock(100 * time.Microsecond)
 
-	PlotRate         = false // rate.#.xpl, accel*.#.apl
+	PlotRate         = false // rate.#.xpl (incl. delivery rate), accel*.#.apl
 	PlotRateInterval = Clock(100 * time.Microsecond)
 )
 
@@ -205,6 +421,21 @@ const (
 	PlotThroughputPerRTT = 1
 )
 
+// Sender: cwnd change attribution.  If CwndAttribution is true, the number of
+// cwnd changes and bytes added and removed are logged per cause for each flow
+// at the end of the run, and if CwndTimelineInterval > 0, the same is written
+// per interval to cwnd-causes.#.tsv.
+const (
+	CwndAttribution      = false
+	CwndTimelineInterval = Clock(1 * time.Second)
+)
+
+// Sender: structured trace events to trace.jsonl (see trace.go), for the
+// selected categories of: TraceCwnd, TraceSlowStartExit, TraceStage,
+// TraceSignalIgnored, TraceProbe and TraceState, or TraceAll.  This may be
+// overridden at runtime with the -trace flag, e.g. -trace cwnd,ss-exit.
+var Trace = TraceNone
+
 ////////////////
 //
 // Receiver Settings
@@ -221,6 +452,71 @@ const (
 	QuickACKSignal = true
 )
 
+// Receiver: ACK policies
+//
+// Each flow is ACKed according to its policy in FlowACKPolicy, or
+// DefaultACKPolicy for flows not listed, which uses the delayed ACK settings
+// above.  Policies may ACK every N segments, start in Linux-style quickack
+// mode, thin ACKs to 1 in N (keeping the newest), and coalesce bursts
+// arriving within a window into one ACK, as with GRO.  Thinned ACKs lose
+// their SCE feedback, while AccECN counters survive.
+var (
+	DefaultACKPolicy = ACKPolicy{
+		DelayedACKTime, // DelayedACKTime
+		QuickACKSignal, // QuickACKSignal
+		2,              // Every
+		0,              // QuickACKs
+		1,              // Thin
+		0,              // GROWindow
+	}
+
+	// Linux-like delayed ACKs, with quickack mode at the start and GRO
+	LinuxACKs = ACKPolicy{
+		Clock(40 * time.Millisecond), // DelayedACKTime
+		false,                        // QuickACKSignal
+		2,                            // Every
+		16,                           // QuickACKs (TCP_MAX_QUICKACKS)
+		1,                            // Thin
+		Clock(50 * time.Microsecond), // GROWindow
+	}
+
+	// aggressive ACK thinning, sending only 1 in 8 ACKs
+	ThinACKs = ACKPolicy{
+		DelayedACKTime, // DelayedACKTime
+		QuickACKSignal, // QuickACKSignal
+		2,              // Every
+		0,              // QuickACKs
+		8,              // Thin
+		0,              // GROWindow
+	}
+
+	FlowACKPolicy = []ACKPolicy{
+		//LinuxACKs,
+		//ThinACKs,
+	}
+)
+
+// Receiver: ACK policy params
+const (
+	GROMaxBytes      = Bytes(65536)                // max coalesced bytes
+	ACKThinFlushTime = Clock(1 * time.Millisecond) // send held thinned ACK
+)
+
+// Receiver: ECN feedback
+//
+// AccurateECN: if true, ACKs carry AccECN (RFC 9768) style cumulative counters
+// of CE marked packets and bytes, instead of setting ECE.  This gives the
+// sender exact marking feedback, even with delayed ACKs.
+//
+// AccurateSCE: if true, ACKs carry the same style of counters for SCE marked
+// packets and bytes, instead of setting ESCE, and SCE marks don't force
+// immediate ACKs.  The sender responds once per newly marked segment, so the
+// SCE response doesn't depend on the ACK frequency.
+const (
+	AccurateECN = false
+	AccurateSCE = false
+)
+
 ////////////////
 //
 // Advanced Settings
@@ -233,6 +529,14 @@ const (
 	Tau    = 64     // SCE-MD scale factor
 )
 
+// DefaultMDScaling contains the default MD-Scaling params for Reno and Reno2.
+// CUBIC and Scalable take Tau and their CE MD in their options, and the
+// MD-Scaling Responders take them as fields, so each may be set per-flow.
+var DefaultMDScaling = MDScaling{
+	Tau,  // Tau
+	CEMD, // CEMD
+}
+
 // Sender: Slow-Start defaults
 const (
 	DefaultSSGrowth        = SSGrowthABC2
@@ -240,6 +544,13 @@ const (
 	DefaultSSExitThreshold = Tau / 2 // e.g. 0, Tau, Tau/2 or Tau/4
 )
 
+// DefaultStdSS contains the default options for StdSS.
+var DefaultStdSS = StdSSOptions{
+	DefaultSSGrowth,        // Growth
+	DefaultSSBaseReduction, // BaseReduction
+	DefaultSSExitThreshold, // ExitThreshold
+}
+
 // SSGrowth selects the growth strategy for slow-start.
 type SSGrowth int
 
@@ -258,15 +569,51 @@ const (
 	EsspSCENoResponse  = true // if true, skip normal response to SCE
 )
 
+// DefaultEssp contains the default options for Essp.
+var DefaultEssp = EsspOptions{
+	EsspHalfKExit,      // HalfKExit
+	EsspDelayThreshold, // DelayThreshold
+	EsspCWNDTargeting,  // CWNDTargeting
+}
+
+// Slow-Start: Paced Chirping params
+const (
+	PCChirpLen     = 16  // packets per chirp
+	PCGapSpread    = 2.0 // first gap is x * average, last gap is average / x
+	PCExcursionLen = 3   // consecutive queue delay increases for excursion
+)
+
+// Slow-Start: Careful Resume params (draft-ietf-tsvwg-careful-resume)
+const (
+	CRJumpFactor   = 0.5  // jump cwnd as a fraction of saved cwnd
+	CRMinRTTFactor = 0.5  // RTT confirmed if >= x * saved RTT
+	CRMaxRTTFactor = 10.0 // RTT confirmed if <= x * saved RTT
+)
+
 // Sender: TCP params
 const (
 	MTU       = Bytes(1500)
-	HeaderLen = 20 + 20 + 12 // IPv4 + TCP + timestamps
+	HeaderLen = sim.HeaderLen // IPv4 + TCP + timestamps
 	MSS       = MTU - HeaderLen
-	IW        = 10 * MSS
+	IWSegs    = 10 // initial window in segments
+	IW        = IWSegs * MSS
+	DupThresh = 3     // dup ACKs for classic loss detection
 	RTTAlpha  = 0.125 // RFC 6298
 )
 
+// Sender: RACK-style reordering tolerance (RFC 8985).  Scim doesn't handle
+// loss, so segments that would be deemed lost by RACK, or by DupThresh dup
+// ACKs, are only counted, and logged for flows that saw reordering.  Each
+// spurious detection widens the reordering window by the min RTT / divisor, up
+// to srtt, as on DSACK.
+const RACKReoWndDivisor = 4
+
+// Sender: reordering detection.  If DetectReordering is true, each flow runs
+// the dup ACK and RACK-style detection above, and logs the results.  It
+// defaults to true with a reorderer or multipath, so flows on a single,
+// in-order path skip the per-segment bookkeeping.
+var DetectReordering = UseReorderer != nil || UseMultipath != nil
+
 // Sender: CUBIC params
 const (
 	CubicBeta            = 0.7  // RFC 9438 Section 4.6
@@ -274,8 +621,25 @@ const (
 	CubicFastConvergence = true // RFC 9438 Section 4.7
 )
 
-// CubicBetaSCE is the MD performed by CUBIC in response to an SCE.
-var CubicBetaSCE = math.Pow(CubicBeta, 1.0/Tau)
+// DefaultCUBIC contains the default options for CUBIC.
+var DefaultCUBIC = CUBICOptions{
+	CubicBeta,            // Beta
+	Tau,                  // Tau
+	CubicC,               // C
+	CubicFastConvergence, // FastConvergence
+}
+
+// Sender: Linux CUBIC and HyStart params (tcp_cubic.c module defaults)
+const (
+	LinuxCubicHZ              = 1000                        // kernel HZ
+	LinuxCubicBeta            = 717                         // beta * 1024
+	LinuxCubicBicScale        = 41                          // C * 1024 / 10
+	LinuxCubicFastConvergence = true                        // fast_convergence
+	LinuxCubicTCPFriendliness = true                        // tcp_friendliness
+	LinuxHyStartLowWindow     = 16                          // min cwnd, packets
+	LinuxHyStartAckDelta      = Clock(2 * time.Millisecond) // ACK-train spacing
+	LinuxHyStartDetectMode    = HyStartAckTrain | HyStartDelay
+)
 
 // Sender: Scalable params
 const (
@@ -285,8 +649,50 @@ const (
 	ScalableRenoSmooth = false      // if true, use per-ACK Reno growth
 )
 
-// ScalableBetaSCE is the MD performed by Scalable in response to an SCE.
-var ScalableBetaSCE = math.Pow(ScalableCEMD, 1.0/Tau)
+// DefaultScalable contains the default options for Scalable.
+var DefaultScalable = ScalableOptions{
+	ScalableCEMD,       // CEMD
+	Tau,                // Tau
+	ScalableAlpha,      // Alpha
+	ScalableLwnd,       // Lwnd
+	ScalableRenoSmooth, // RenoSmooth
+}
+
+// Sender: DCTCP and Prague params
+const (
+	DCTCPGain      = 1.0 / 16                     // EWMA gain g for alpha
+	DCTCPAlphaInit = 1.0                          // initial alpha
+	PragueRTTVirt  = Clock(25 * time.Millisecond) // virtual RTT for AI
+)
+
+// Sender: BBR params
+const (
+	BBRMinRTTFilterLen    = Clock(10 * time.Second)       // min RTT window
+	BBRProbeRTTDuration   = Clock(200 * time.Millisecond) // min ProbeRTT time
+	BBRv1BwFilterLen      = 10                            // max bw window, rounds
+	BBRv3ProbeRTTInterval = Clock(5 * time.Second)        // ProbeRTT interval
+	BBRv3Beta             = 0.7                           // inflight_hi MD
+	BBRv3Headroom         = 0.15                          // inflight_hi headroom
+	BBRv3ECNThresh        = 0.5                           // CE fraction too high
+	BBRv3ECNFactor        = 1.0 / 3                       // inflight_lo MD * alpha
+	BBRv3ECNGain          = 1.0 / 16                      // EWMA gain for alpha
+	BBRv3FullECNRounds    = 2                             // CE rounds to exit Startup
+	BBRv3ECNMaxRTT        = Clock(0)                      // 0 for no limit (Linux 5ms)
+)
+
+// Sender: Vegas, FAST and LEDBAT params
+const (
+	VegasAlpha          = 2                             // min queued, packets
+	VegasBeta           = 4                             // max queued, packets
+	VegasGamma          = 1                             // SS exit, packets
+	FASTAlpha           = 20                            // queued, packets
+	FASTGamma           = 0.5                           // window update gain
+	LEDBATTarget        = Clock(100 * time.Millisecond) // max per RFC 6817
+	LEDBATGain          = 1.0                           // cwnd gain
+	LEDBATMinCwnd       = 2                             // packets
+	LEDBATCurrentFilter = 4                             // RTT samples
+	LEDBATBaseHistory   = 10                            // minutes
+)
 
 // Sender: MASLO params
 const (
@@ -301,10 +707,48 @@ const (
 	MasloMinimumCwnd        = Bytes(float64(4*MSS) * MasloCwndScaleFactor)
 )
 
+// DefaultMaslo contains the default options for Maslo.
+var DefaultMaslo = MasloOptions{
+	MasloBeta,               // Beta
+	MasloM,                  // M
+	MasloOrttAdjustment,     // OrttAdjustment
+	MasloProbeThreshold,     // ProbeThreshold
+	MasloCwndScaleFactor,    // CwndScaleFactor
+	MasloBandwidthProbing,   // BandwidthProbing
+	MasloSCEMDApproximation, // SCEMDApproximation
+	MasloAdjustSafeRTT,      // AdjustSafeRTT
+	MasloMinimumCwnd,        // MinimumCwnd
+	DefaultEssp,             // Probe
+}
+
 // Sender: pacing params
 const (
-	DefaultPacingSSRatio = 1.0 // Linux default == 2.0
-	DefaultPacingCARatio = 1.0 // Linux default == 1.2
+	DefaultPacingSSRatio  = 1.0                         // Linux default == 2.0
+	DefaultPacingCARatio  = 1.0                         // Linux default == 1.2
+	DefaultPacingBurst    = 1                           // segments per interval
+	PacingTSOAutosizeTime = Clock(1 * time.Millisecond) // sk_pacing_shift 10
+	PacingTSOMaxBytes     = Bytes(65536)                // GSO max size
+	PacingTSOWinDivisor   = 3                           // tcp_tso_win_divisor
+	TSQLimitBytes         = Bytes(4 << 20)              // tcp_limit_output_bytes
+)
+
+// Pacing and NoPacing contain the default pacing options for Flows, with
+// pacing enabled and disabled.
+var (
+	Pacing = PacingOptions{
+		true,                 // Enabled
+		DefaultPacingSSRatio, // SSRatio
+		DefaultPacingCARatio, // CARatio
+		DefaultPacingBurst,   // Burst
+		false,                // TSOAutosize
+	}
+	NoPacing = PacingOptions{
+		false,                // Enabled
+		DefaultPacingSSRatio, // SSRatio
+		DefaultPacingCARatio, // CARatio
+		DefaultPacingBurst,   // Burst
+		false,                // TSOAutosize
+	}
 )
 
 // Sender: HyStart++ (RFC 9406)
@@ -318,6 +762,19 @@ const (
 	HyStartLNoPacing   = 8                            // default 8
 )
 
+// DefaultHyStartPP contains the default options for HyStartPP.
+var DefaultHyStartPP = HyStartPPOptions{
+	DefaultSSGrowth,        // Growth
+	DefaultSSExitThreshold, // ExitThreshold
+	HyMinRTTThresh,         // MinRTTThresh
+	HyMaxRTTThresh,         // MaxRTTThresh
+	HyMinRTTDivisor,        // MinRTTDivisor
+	HyNRTTSample,           // NRTTSample
+	HyCSSGrowthDivisor,     // CSSGrowthDivisor
+	HyCSSRounds,            // CSSRounds
+	HyStartLNoPacing,       // LNoPacing
+}
+
 // Iface: AQM queue length restriction at which panic occurs
 const IfaceHardQueueLen = 1000000
 
@@ -326,6 +783,28 @@ const (
 	DelticJitterCompensation = true
 )
 
+// Iface: PIE params (RFC 8033)
+const (
+	PieAlpha            = 0.125                         // integral gain (Hz)
+	PieBeta             = 1.25                          // proportional gain (Hz)
+	PieMaxBurst         = Clock(150 * time.Millisecond) // burst allowance
+	PieMarkECNThreshold = 0.1                           // max prob to mark ECT
+)
+
+// Iface: PI2 and DualPI2 params (RFC 9332)
+const (
+	Pi2Alpha      = 0.16                         // integral gain (Hz)
+	Pi2Beta       = 3.2                          // proportional gain (Hz)
+	Pi2K          = 2.0                          // coupling factor
+	DualPi2TShift = Clock(50 * time.Millisecond) // time shift for L queue
+)
+
+// Sender: random number generator seed for CCAs that use randomness
+const CCASeed = 1
+
+// Iface: random number generator seed for AQMs and ECNRewriters
+const AQMSeed = 1
+
 // main
 const (
 	ProfileCPU    = false // do CPU profiling to scim-cpu.prof
@@ -0,0 +1,303 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math"
+)
+
+// CoupledAlgorithm selects the algorithm for coupled congestion control.
+type CoupledAlgorithm int
+
+const (
+	LIA   CoupledAlgorithm = iota // Linked Increases (RFC 6356)
+	OLIA                          // Opportunistic LIA (Khalili et al.)
+	BALIA                         // Balanced LIA (Peng et al.)
+)
+
+// String implements fmt.Stringer.
+func (a CoupledAlgorithm) String() string {
+	switch a {
+	case LIA:
+		return "lia"
+	case OLIA:
+		return "olia"
+	case BALIA:
+		return "balia"
+	}
+	return "unknown"
+}
+
+// Coupling is the state shared by the subflows of a multipath connection.
+// Each subflow is a Flow using a Coupled CCA with the same Coupling, and joins
+// the Coupling on its first ACK in congestion avoidance.
+type Coupling struct {
+	algorithm CoupledAlgorithm
+	subflow   []*Coupled
+}
+
+// NewCoupling returns a new Coupling for the given algorithm.
+func NewCoupling(algorithm CoupledAlgorithm) *Coupling {
+	return &Coupling{
+		algorithm, // algorithm
+		nil,       // subflow
+	}
+}
+
+// join adds the given subflow, if it hasn't already joined.
+func (c *Coupling) join(subflow *Coupled) {
+	for _, s := range c.subflow {
+		if s == subflow {
+			return
+		}
+	}
+	c.subflow = append(c.subflow, subflow)
+}
+
+// each calls the given function for the active subflows with an RTT sample,
+// with their cwnd in segments and srtt in seconds.
+func (c *Coupling) each(f func(s *Coupled, w, rtt float64)) {
+	for _, s := range c.subflow {
+		if !s.flow.active || s.flow.srtt == 0 {
+			continue
+		}
+		f(s, s.flow.cwnd.Segments(s.flow.mss()), s.flow.srtt.Seconds())
+	}
+}
+
+// Coupled implements coupled congestion control for one subflow of a multipath
+// connection, using LIA, OLIA or BALIA.  Each increases cwnd per ACK by an
+// amount that depends on the cwnd and RTT of all subflows, so the connection
+// is no more aggressive than a single Reno flow on its best path, and moves
+// traffic away from more congested paths.  On CE, LIA and OLIA reduce cwnd by
+// the CE MD, and BALIA by up to 1.5 times that for subflows with a low rate.
+//
+// On SCE, cwnd is reduced by the Tau'th root of the subflow's CE response, as
+// with MD-Scaling, so SCE feeds the coupled decrease at a finer grain, and
+// BALIA's balancing applies to SCE as well.  For OLIA, Tau SCEs count as one
+// loss when tracking the bytes acked between losses.
+type Coupled struct {
+	MDScaling
+	coupling   *Coupling
+	flow       *Flow
+	growRem    float64
+	lossPrior  Bytes // OLIA: bytes acked between the last two losses
+	lossLast   Bytes // OLIA: bytes acked since the last loss
+	sceCount   int   // OLIA: SCEs since the last loss
+	sceHistory *clockRing
+}
+
+// NewCoupled returns a new Coupled, for a subflow of the connection with the
+// given Coupling.
+func NewCoupled(coupling *Coupling, mds MDScaling) *Coupled {
+	return &Coupled{
+		mds,                   // MDScaling
+		coupling,              // coupling
+		nil,                   // flow
+		0,                     // growRem
+		0,                     // lossPrior
+		0,                     // lossLast
+		0,                     // sceCount
+		newClockRing(mds.Tau), // sceHistory
+	}
+}
+
+// slowStartExit implements slowStartExiter.
+func (c *Coupled) slowStartExit(flow *Flow, node Node) {
+	c.flow = flow
+	c.coupling.join(c)
+}
+
+// handleCE implements handleCEer.
+func (c *Coupled) handleCE(flow *Flow, node Node) {
+	c.flow = flow
+	c.coupling.join(c)
+	if flow.receiveNext > flow.signalNext {
+		flow.setCWND(Bytes(float64(flow.cwnd)*c.md()), node)
+		flow.signalNext = flow.seq
+		c.loss()
+	}
+}
+
+// handleSCE implements handleSCEer.
+func (c *Coupled) handleSCE(flow *Flow, node Node) {
+	c.flow = flow
+	c.coupling.join(c)
+	if c.sceHistory.add(node.Now(), node.Now()-flow.srtt) &&
+		flow.receiveNext > flow.signalNext {
+		m := math.Pow(c.md(), 1.0/float64(c.Tau))
+		flow.setCWND(Bytes(float64(flow.cwnd)*m), node)
+		if c.sceCount++; c.sceCount >= c.Tau {
+			c.loss()
+		}
+	}
+}
+
+// loss records a loss, or its equivalent in SCEs, for OLIA.
+func (c *Coupled) loss() {
+	c.lossPrior = c.lossLast
+	c.lossLast = 0
+	c.sceCount = 0
+}
+
+// md returns the multiplicative decrease for CE.
+func (c *Coupled) md() float64 {
+	if c.coupling.algorithm != BALIA {
+		return c.CEMD
+	}
+	a := math.Min(c.baliaAlpha(), 1.5)
+	return math.Max(1-(1-c.CEMD)*a, 0)
+}
+
+// grow implements CCA.
+func (c *Coupled) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	c.flow = flow
+	c.coupling.join(c)
+	c.lossLast += acked
+	if pkt.ECE || pkt.ESCE || flow.srtt == 0 {
+		return
+	}
+	var i float64
+	switch c.coupling.algorithm {
+	case LIA:
+		i = c.liaIncrease()
+	case OLIA:
+		i = c.oliaIncrease()
+	case BALIA:
+		i = c.baliaIncrease()
+	}
+	g := acked.Segments(flow.mss())*i*float64(flow.mss()) + c.growRem
+	d := math.Trunc(g)
+	c.growRem = g - d
+	flow.setCWND(Bytes(float64(flow.cwnd)+d), node)
+}
+
+// liaIncrease returns the LIA increase in segments per segment acked, which is
+// min(alpha / w_total, 1 / w) from RFC 6356, or equivalently
+// min(max(w_i / rtt_i^2) / (sum(w_i / rtt_i))^2, 1 / w).
+func (c *Coupled) liaIncrease() float64 {
+	var m, s float64
+	c.coupling.each(func(_ *Coupled, w, rtt float64) {
+		m = math.Max(m, w/(rtt*rtt))
+		s += w / rtt
+	})
+	w := c.flow.cwnd.Segments(c.flow.mss())
+	if s == 0 {
+		return 1 / w
+	}
+	return math.Min(m/(s*s), 1/w)
+}
+
+// oliaIncrease returns the OLIA increase in segments per segment acked, which
+// is (w / rtt^2) / (sum(w_p / rtt_p))^2 + alpha / w.  Alpha shifts window from
+// the subflows with the largest windows to those that are best by the bytes
+// acked between losses (l^2 / rtt), but have smaller windows.
+func (c *Coupled) oliaIncrease() float64 {
+	var n int
+	var s, wMax, bMax float64
+	c.coupling.each(func(p *Coupled, w, rtt float64) {
+		n++
+		s += w / rtt
+		wMax = math.Max(wMax, w)
+		bMax = math.Max(bMax, p.oliaQuality(rtt))
+	})
+	w := c.flow.cwnd.Segments(c.flow.mss())
+	rtt := c.flow.srtt.Seconds()
+	if s == 0 {
+		return 1 / w
+	}
+	var nM, nBM int // |M| and |B\M|
+	inM, inBM := false, false
+	c.coupling.each(func(p *Coupled, pw, prtt float64) {
+		m := pw == wMax
+		b := p.oliaQuality(prtt) == bMax
+		if m {
+			nM++
+		} else if b {
+			nBM++
+		}
+		if p == c {
+			inM, inBM = m, b && !m
+		}
+	})
+	var a float64
+	if nBM > 0 {
+		if inBM {
+			a = 1 / float64(n*nBM)
+		} else if inM {
+			a = -1 / float64(n*nM)
+		}
+	}
+	return (w/(rtt*rtt))/(s*s) + a/w
+}
+
+// oliaQuality returns l^2 / rtt for OLIA, where l is the greater of the bytes
+// acked between the last two losses, and since the last loss.
+func (c *Coupled) oliaQuality(rtt float64) float64 {
+	l := max(c.lossPrior, c.lossLast).Segments(c.flow.mss())
+	return l * l / rtt
+}
+
+// baliaIncrease returns the BALIA increase in segments per segment acked, which
+// is (x / (rtt * sum(x_k)^2)) * ((1 + alpha) / 2) * ((4 + alpha) / 5), where x
+// is the rate w / rtt.
+func (c *Coupled) baliaIncrease() float64 {
+	var s float64
+	c.coupling.each(func(_ *Coupled, w, rtt float64) {
+		s += w / rtt
+	})
+	w := c.flow.cwnd.Segments(c.flow.mss())
+	rtt := c.flow.srtt.Seconds()
+	if s == 0 {
+		return 1 / w
+	}
+	x := w / rtt
+	a := c.baliaAlpha()
+	return x / (rtt * s * s) * ((1 + a) / 2) * ((4 + a) / 5)
+}
+
+// baliaAlpha returns max(x_k) / x for BALIA, or 1 if the subflow has no rate.
+func (c *Coupled) baliaAlpha() float64 {
+	if c.flow == nil || c.flow.srtt == 0 {
+		return 1
+	}
+	var m float64
+	c.coupling.each(func(_ *Coupled, w, rtt float64) {
+		m = math.Max(m, w/rtt)
+	})
+	x := c.flow.cwnd.Segments(c.flow.mss()) / c.flow.srtt.Seconds()
+	if m == 0 || x == 0 {
+		return 1
+	}
+	return m / x
+}
+
+// Stop implements Stopper.
+func (c *Coupled) Stop(node Node) error {
+	if c.flow == nil {
+		return nil
+	}
+	var t Bytes
+	for _, s := range c.coupling.subflow {
+		t += s.flow.acked
+	}
+	var p float64
+	if t > 0 {
+		p = 100 * float64(c.flow.acked) / float64(t)
+	}
+	node.Logf("flow:%d %s subflow %d of %d acked:%d share:%.1f%%",
+		c.flow.id, c.coupling.algorithm, c.index()+1, len(c.coupling.subflow),
+		c.flow.acked, p)
+	return nil
+}
+
+// index returns the index of the subflow in the Coupling.
+func (c *Coupled) index() int {
+	for i, s := range c.coupling.subflow {
+		if s == c {
+			return i
+		}
+	}
+	return -1
+}
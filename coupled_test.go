@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testSubflows returns two joined subflows for the given algorithm, with cwnds
+// of 10 and 20 segments, srtts of 10 and 40 ms, and the given bytes acked since
+// the last loss, in segments.
+func testSubflows(algorithm CoupledAlgorithm, loss0, loss1 int) (
+	[]*Coupled, []Flow) {
+	p := NewCoupling(algorithm)
+	c := []*Coupled{
+		NewCoupled(p, MDScaling{Tau, 0.5}),
+		NewCoupled(p, MDScaling{Tau, 0.5}),
+	}
+	f := []Flow{
+		NewFlow(0, ECN, NoSCE, NoSS{}, NoResponse{}, c[0], NoPacing, true),
+		NewFlow(1, ECN, NoSCE, NoSS{}, NoResponse{}, c[1], NoPacing, true),
+	}
+	for i, s := range []struct {
+		cwnd int
+		srtt time.Duration
+		loss int
+	}{
+		{10, 10 * time.Millisecond, loss0},
+		{20, 40 * time.Millisecond, loss1},
+	} {
+		f[i].cwnd = Bytes(s.cwnd) * f[i].mss()
+		f[i].srtt = Clock(s.srtt)
+		c[i].lossLast = Bytes(s.loss) * f[i].mss()
+		c[i].flow = &f[i]
+		p.join(c[i])
+	}
+	return c, f
+}
+
+func TestCoupledIncrease(t *testing.T) {
+	// With w = (10, 20) and rtt = (0.01, 0.04), sum(w / rtt) = 1500.
+	tests := []struct {
+		name      string
+		algorithm CoupledAlgorithm
+		loss0     int
+		loss1     int
+		want      [2]float64
+	}{
+		// min(max(w / rtt^2) / 1500^2, 1 / w) = 1e5 / 2.25e6
+		{"lia", LIA, 0, 0, [2]float64{1e5 / 2.25e6, 1e5 / 2.25e6}},
+		// the best path by l^2 / rtt is the one with the largest window, so
+		// B\M is empty, and alpha is 0: (w / rtt^2) / 1500^2
+		{"olia best is max window", OLIA, 10, 100,
+			[2]float64{1e5 / 2.25e6, 12500 / 2.25e6}},
+		// the best path has the smaller window, so it's in B\M and gets
+		// alpha = 1 / (2 * 1), while the max window path gets -1 / (2 * 1)
+		{"olia best is not max window", OLIA, 100, 10,
+			[2]float64{1e5/2.25e6 + 0.5/10, 12500/2.25e6 - 0.5/20}},
+		// x = (1000, 500), so alpha = (1, 2), and the increase is
+		// x / (rtt * 1500^2) * ((1 + alpha) / 2) * ((4 + alpha) / 5)
+		{"balia", BALIA, 0, 0,
+			[2]float64{1000 / (0.01 * 2.25e6), 500 / (0.04 * 2.25e6) * 1.8}},
+	}
+	for _, c := range tests {
+		s, _ := testSubflows(c.algorithm, c.loss0, c.loss1)
+		for i := range s {
+			var g float64
+			switch c.algorithm {
+			case LIA:
+				g = s[i].liaIncrease()
+			case OLIA:
+				g = s[i].oliaIncrease()
+			case BALIA:
+				g = s[i].baliaIncrease()
+			}
+			if math.Abs(g-c.want[i]) > 1e-9 {
+				t.Errorf("%s: subflow %d increase %g, want %g", c.name, i, g,
+					c.want[i])
+			}
+		}
+	}
+}
+
+func TestCoupledDecrease(t *testing.T) {
+	tests := []struct {
+		algorithm CoupledAlgorithm
+		want      [2]float64
+	}{
+		{LIA, [2]float64{0.5, 0.5}},
+		{OLIA, [2]float64{0.5, 0.5}},
+		// alpha = (1, 2), limited to 1.5, so MD = 1 - (1 - 0.5) * alpha
+		{BALIA, [2]float64{0.5, 0.25}},
+	}
+	for _, c := range tests {
+		s, _ := testSubflows(c.algorithm, 0, 0)
+		for i := range s {
+			if m := s[i].md(); math.Abs(m-c.want[i]) > 1e-9 {
+				t.Errorf("%s: subflow %d MD %g, want %g", c.algorithm, i, m,
+					c.want[i])
+			}
+		}
+	}
+}
+
+func TestCoupledGrow(t *testing.T) {
+	s, f := testSubflows(LIA, 0, 0)
+	w := f[0].cwnd
+	s[0].grow(10*f[0].mss(), Packet{}, &f[0], &testNode{})
+	// 10 segments acked at 1e5 / 2.25e6 segments each, with the fraction of a
+	// byte kept for the next ACK
+	g := 10 * 1e5 / 2.25e6 * float64(f[0].mss())
+	if d := f[0].cwnd - w; d != Bytes(g) {
+		t.Errorf("cwnd grew by %d bytes, want %d", d, Bytes(g))
+	}
+	if r := s[0].growRem; math.Abs(r-(g-math.Trunc(g))) > 1e-6 {
+		t.Errorf("growth remainder %g, want %g", r, g-math.Trunc(g))
+	}
+}
@@ -0,0 +1,357 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/bits"
+	"time"
+)
+
+// LinuxCUBIC is a conformance mode version of CUBIC, ported from Linux
+// tcp_cubic.c for comparison with Linux testbed captures.  It uses the kernel's
+// integer arithmetic, including the cube root approximation, bic_scale, the
+// TCP friendliness region, jiffies-based time and packet-based cwnd with
+// tcp_cong_avoid_ai.  For the Linux slow-start, use LinuxHyStart.  As in Linux,
+// cwnd is not increased while in CWR after a CE mark, though the reduction to
+// ssthresh is done immediately, rather than by PRR.
+type LinuxCUBIC struct {
+	cnt         uint32 // increase cwnd by 1 after this many ACKed packets
+	lastMaxCwnd uint32 // last maximum cwnd
+	lastCwnd    uint32 // the last cwnd
+	lastTime    uint32 // time when lastCwnd was updated
+	originPoint uint32 // origin point of bic function
+	k           uint32 // time to origin point from epoch start
+	epochStart  uint32 // beginning of an epoch, or 0
+	ackCnt      uint32 // number of ACKed packets
+	tcpCwnd     uint32 // estimated Reno cwnd
+	sndCwndCnt  uint32 // linear increase counter
+	ackedRem    Bytes  // ACKed bytes less than one MSS
+}
+
+// NewLinuxCUBIC returns a new LinuxCUBIC.
+func NewLinuxCUBIC() *LinuxCUBIC {
+	return &LinuxCUBIC{
+		0, // cnt
+		0, // lastMaxCwnd
+		0, // lastCwnd
+		0, // lastTime
+		0, // originPoint
+		0, // k
+		0, // epochStart
+		0, // ackCnt
+		0, // tcpCwnd
+		0, // sndCwndCnt
+		0, // ackedRem
+	}
+}
+
+const (
+	linuxCubicBetaScale    = 1024 // BICTCP_BETA_SCALE
+	linuxCubicHZShift      = 10   // BICTCP_HZ
+	linuxCubicCubeRttScale = LinuxCubicBicScale * 10
+	linuxCubicTCPScale     = 8 * (linuxCubicBetaScale + LinuxCubicBeta) / 3 /
+		(linuxCubicBetaScale - LinuxCubicBeta) // beta_scale
+	linuxCubicCubeFactor = (1 << (10 + 3*linuxCubicHZShift)) /
+		linuxCubicCubeRttScale
+)
+
+// jiffies returns the given time in jiffies.
+func jiffies(t Clock) uint32 {
+	return uint32(t / (Clock(time.Second) / LinuxCubicHZ))
+}
+
+// usecsToJiffies converts microseconds to jiffies, rounding up.
+func usecsToJiffies(u uint32) uint32 {
+	return uint32((uint64(u)*LinuxCubicHZ + 999999) / 1000000)
+}
+
+// handleCE implements handleCEer.
+func (c *LinuxCUBIC) handleCE(flow *Flow, node Node) {
+	if flow.receiveNext > flow.signalNext {
+		w := uint32(flow.cwnd / flow.mss())
+		flow.setCWND(Bytes(c.recalcSsthresh(w))*flow.mss(), node)
+		c.sndCwndCnt = 0
+		flow.signalNext = flow.seq
+	}
+}
+
+// ceMD implements ceMDer.
+func (c *LinuxCUBIC) ceMD() float64 {
+	return float64(LinuxCubicBeta) / linuxCubicBetaScale
+}
+
+// recalcSsthresh ends the epoch, updates lastMaxCwnd and returns the new
+// ssthresh, as in cubictcp_recalc_ssthresh.
+func (c *LinuxCUBIC) recalcSsthresh(cwnd uint32) uint32 {
+	c.epochStart = 0
+	if cwnd < c.lastMaxCwnd && LinuxCubicFastConvergence {
+		c.lastMaxCwnd = (cwnd * (linuxCubicBetaScale + LinuxCubicBeta)) /
+			(2 * linuxCubicBetaScale)
+	} else {
+		c.lastMaxCwnd = cwnd
+	}
+	return max((cwnd*LinuxCubicBeta)/linuxCubicBetaScale, 2)
+}
+
+// grow implements CCA.
+func (c *LinuxCUBIC) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE || flow.rs.appLimited {
+		return
+	}
+	if flow.receiveNext <= flow.signalNext { // CWR
+		return
+	}
+	c.ackedRem += acked
+	a := uint32(c.ackedRem / flow.mss())
+	c.ackedRem %= flow.mss()
+	if a == 0 {
+		return
+	}
+	w := uint32(flow.cwnd / flow.mss())
+	c.update(w, a, flow, node)
+	w = c.congAvoidAI(w, c.cnt, a)
+	flow.setCWND(Bytes(w)*flow.mss(), node)
+}
+
+// congAvoidAI performs the linear increase in tcp_cong_avoid_ai, returning the
+// new cwnd.
+func (c *LinuxCUBIC) congAvoidAI(cwnd, w, acked uint32) uint32 {
+	if c.sndCwndCnt >= w {
+		c.sndCwndCnt = 0
+		cwnd++
+	}
+	c.sndCwndCnt += acked
+	if c.sndCwndCnt >= w {
+		d := c.sndCwndCnt / w
+		c.sndCwndCnt -= d * w
+		cwnd += d
+	}
+	return cwnd
+}
+
+// update updates cnt, as in bictcp_update.
+func (c *LinuxCUBIC) update(cwnd, acked uint32, flow *Flow, node Node) {
+	now := jiffies(node.Now())
+	c.ackCnt += acked
+	if c.lastCwnd == cwnd && int32(now-c.lastTime) <= LinuxCubicHZ/32 {
+		return
+	}
+	// cnt may be updated at most once per jiffy
+	if c.epochStart == 0 || now != c.lastTime {
+		c.updateCubic(now, cwnd, acked, flow)
+	}
+	if LinuxCubicTCPFriendliness {
+		d := (cwnd * linuxCubicTCPScale) >> 3
+		for c.ackCnt > d {
+			c.ackCnt -= d
+			c.tcpCwnd++
+		}
+		if c.tcpCwnd > cwnd {
+			d = c.tcpCwnd - cwnd
+			if m := cwnd / d; c.cnt > m {
+				c.cnt = m
+			}
+		}
+	}
+	// grow by at most 1.5x per RTT
+	c.cnt = max(c.cnt, 2)
+}
+
+// updateCubic updates cnt from the cubic function.
+func (c *LinuxCUBIC) updateCubic(now, cwnd, acked uint32, flow *Flow) {
+	c.lastCwnd = cwnd
+	c.lastTime = now
+	if c.epochStart == 0 {
+		c.epochStart = now
+		c.ackCnt = acked
+		c.tcpCwnd = cwnd
+		if c.lastMaxCwnd <= cwnd {
+			c.k = 0
+			c.originPoint = cwnd
+		} else {
+			d := uint64(c.lastMaxCwnd - cwnd)
+			c.k = cubicRoot(linuxCubicCubeFactor * d)
+			c.originPoint = c.lastMaxCwnd
+		}
+	}
+	d := uint32(flow.minRtt / Clock(time.Microsecond))
+	t := uint64(int32(now-c.epochStart)) + uint64(usecsToJiffies(d))
+	t = (t << linuxCubicHZShift) / LinuxCubicHZ
+	var o uint64
+	if t < uint64(c.k) {
+		o = uint64(c.k) - t
+	} else {
+		o = t - uint64(c.k)
+	}
+	delta := uint32((linuxCubicCubeRttScale * o * o * o) >>
+		(10 + 3*linuxCubicHZShift))
+	var target uint32
+	if t < uint64(c.k) {
+		target = c.originPoint - delta
+	} else {
+		target = c.originPoint + delta
+	}
+	if target > cwnd {
+		c.cnt = cwnd / (target - cwnd)
+	} else {
+		c.cnt = 100 * cwnd
+	}
+	// the initial growth may be too conservative when bandwidth is unknown
+	if c.lastMaxCwnd == 0 && c.cnt > 20 {
+		c.cnt = 20
+	}
+}
+
+// cubicRoot returns the cube root of a, using the table lookup and one
+// Newton-Raphson iteration in the Linux cubic_root.
+func cubicRoot(a uint64) uint32 {
+	v := [...]uint8{
+		0, 54, 54, 54, 118, 118, 118, 118,
+		123, 129, 134, 138, 143, 147, 151, 156,
+		157, 161, 164, 168, 170, 173, 176, 179,
+		181, 185, 187, 190, 192, 194, 197, 199,
+		200, 202, 204, 206, 209, 211, 213, 215,
+		217, 219, 221, 222, 224, 225, 227, 229,
+		231, 232, 234, 236, 237, 239, 240, 242,
+		244, 245, 246, 248, 250, 251, 252, 254,
+	}
+	b := uint32(bits.Len64(a))
+	if b < 7 {
+		return (uint32(v[a]) + 35) >> 6
+	}
+	b = ((b * 84) >> 8) - 1
+	s := a >> (b * 3)
+	x := (uint32(v[s]) + 10) << b >> 6
+	x = 2*x + uint32(a/(uint64(x)*uint64(x-1)))
+	return (x * 341) >> 10
+}
+
+// LinuxHyStartDetect selects the HyStart detection mechanisms.
+type LinuxHyStartDetect int
+
+const (
+	HyStartAckTrain LinuxHyStartDetect = 1 << iota // ACK-train
+	HyStartDelay                                   // delay increase
+)
+
+// LinuxHyStart implements slow-start with the classic HyStart in Linux
+// tcp_cubic.c, which exits when either the ACK train for a round extends past
+// half the min delay (ACK-train detection), or the min RTT of a round
+// increases by a threshold (delay-increase detection).  Cwnd grows by the
+// number of bytes acked.
+type LinuxHyStart struct {
+	roundStart Clock
+	lastAck    Clock
+	endSeq     Seq
+	currRtt    Clock
+	sampleCnt  int
+	delayMin   Clock
+	found      bool
+}
+
+// NewLinuxHyStart returns a new LinuxHyStart.
+func NewLinuxHyStart() *LinuxHyStart {
+	return &LinuxHyStart{
+		0,        // roundStart
+		0,        // lastAck
+		0,        // endSeq
+		ClockMax, // currRtt
+		0,        // sampleCnt
+		0,        // delayMin
+		false,    // found
+	}
+}
+
+// init implements initer.
+func (h *LinuxHyStart) init(flow *Flow, node Node) {
+	h.reset(flow, node)
+}
+
+// reset starts a new round, as in bictcp_hystart_reset.
+func (h *LinuxHyStart) reset(flow *Flow, node Node) {
+	h.roundStart = node.Now()
+	h.lastAck = node.Now()
+	h.endSeq = flow.seq
+	h.currRtt = ClockMax
+	h.sampleCnt = 0
+}
+
+// handleCE implements handleCESSer.
+func (*LinuxHyStart) handleCE(flow *Flow, node Node) (exit bool) {
+	exit = true
+	return
+}
+
+// updateRtt implements updateRtter.
+func (h *LinuxHyStart) updateRtt(rtt Clock, flow *Flow, node Node) {
+	if h.delayMin == 0 || h.delayMin > rtt {
+		h.delayMin = rtt
+	}
+	if !h.found && flow.cwnd >= LinuxHyStartLowWindow*flow.mss() {
+		h.update(rtt, flow, node)
+	}
+}
+
+// update performs HyStart detection, as in hystart_update.
+func (h *LinuxHyStart) update(delay Clock, flow *Flow, node Node) {
+	if flow.receiveNext > h.endSeq {
+		h.reset(flow, node)
+	}
+	now := node.Now()
+	if LinuxHyStartDetectMode&HyStartAckTrain != 0 &&
+		now-h.lastAck <= LinuxHyStartAckDelta {
+		h.lastAck = now
+		t := h.delayMin + h.ackDelay(flow)
+		if !flow.pacing.Enabled {
+			t /= 2
+		}
+		if now-h.roundStart > t {
+			h.found = true
+			node.Logf("flow:%d hystart ack train cwnd:%d", flow.id, flow.cwnd)
+		}
+	}
+	if LinuxHyStartDetectMode&HyStartDelay != 0 {
+		h.currRtt = min(h.currRtt, delay)
+		if h.sampleCnt < linuxHyStartMinSamples {
+			h.sampleCnt++
+		} else if h.currRtt > h.delayMin+max(linuxHyStartDelayMin,
+			min(h.delayMin/8, linuxHyStartDelayMax)) {
+			h.found = true
+			node.Logf("flow:%d hystart delay curr:%sms min:%sms cwnd:%d",
+				flow.id, h.currRtt.StringMS(), h.delayMin.StringMS(),
+				flow.cwnd)
+		}
+	}
+}
+
+const (
+	linuxHyStartMinSamples = 8
+	linuxHyStartDelayMin   = Clock(4 * time.Millisecond)
+	linuxHyStartDelayMax   = Clock(16 * time.Millisecond)
+	linuxHyStartGSOMaxSize = Bytes(65536)
+)
+
+// ackDelay returns the allowance for ACK delay when pacing, as in
+// hystart_ack_delay.
+func (h *LinuxHyStart) ackDelay(flow *Flow) Clock {
+	if !flow.pacing.Enabled {
+		return 0
+	}
+	r := flow.getPacingRate()
+	if r <= 0 {
+		return 0
+	}
+	return min(Clock(time.Millisecond),
+		Clock(TransferTime(r, 4*linuxHyStartGSOMaxSize)))
+}
+
+// grow implements SlowStart.
+func (h *LinuxHyStart) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
+	if h.found {
+		exit = true
+		return
+	}
+	flow.setCWND(flow.cwnd+acked, node)
+	return
+}
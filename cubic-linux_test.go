@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testNode is a Node with a settable clock, for driving CCAs directly.
+type testNode struct {
+	now Clock
+}
+
+func (n *testNode) Timer(delay Clock, data any)  {}
+func (n *testNode) Send(Packet)                  {}
+func (n *testNode) Now() Clock                   { return n.now }
+func (n *testNode) Logf(format string, a ...any) {}
+func (n *testNode) Shutdown()                    {}
+
+// linuxCubicGolden is the cwnd in packets at the end of each round, from a
+// transcription of tcp_cubic.c with HZ=1000, for a 100ms RTT and one ACK per
+// packet, spread evenly over each round.  It starts with a CE at 1000 packets,
+// followed by another CE at round 60, which exercises fast convergence.  This
+// covers the concave region, plateau at W_max and the convex region.
+var linuxCubicGolden = []uint32{
+	710, 720, 729, 738, 747, 756, 764, 773, 781, 789, 797, 804, 811, 818, 825,
+	832, 839, 846, 852, 858, 864, 870, 875, 881, 886, 891, 896, 901, 906, 910,
+	914, 918, 922, 926, 930, 934, 938, 941, 944, 947, 950, 953, 956, 959, 962,
+	965, 967, 969, 971, 973, 975, 977, 979, 981, 982, 984, 985, 986, 987, 989,
+	698, 704, 710, 715, 721, 726, 731, 736, 741, 746, 750, 754, 758, 762, 766,
+	770, 774, 778, 781, 784, 787, 790, 793, 796, 799, 802, 805, 807, 809, 811,
+	813, 815, 817, 819, 821, 822, 824, 825, 826, 827, 829, 830, 831, 832, 833,
+	834, 835, 836, 836, 837, 837, 838, 838, 839, 839, 839, 839, 840, 840, 840,
+	840, 840, 840, 840, 840, 840, 840, 840, 840, 840, 840, 840, 840, 840, 840,
+	840, 840, 840, 840, 840, 840, 840, 840, 840, 841, 841, 841, 842, 842, 842,
+	843, 843, 844, 844, 845, 846, 847, 848, 848, 849, 850, 851, 852, 853, 854,
+	856, 857, 859, 860, 862, 864, 866, 868, 870, 872, 874, 877, 879, 882, 885,
+	888, 891, 894, 897, 900, 903, 907, 910, 914, 918, 922, 926, 931, 936, 940,
+	945, 950, 955, 961, 966,
+}
+
+func TestLinuxCUBICGolden(t *testing.T) {
+	const rtt = Clock(100 * time.Millisecond)
+	c := NewLinuxCUBIC()
+	n := &testNode{Clock(time.Second)}
+	f := NewFlow(0, ECN, NoSCE, NoSS{}, NoResponse{}, c, NoPacing, true)
+	f.minRtt = rtt
+	f.cwnd = 1000 * MSS
+	f.receiveNext = 1
+	c.handleCE(&f, n)
+	for r, g := range linuxCubicGolden {
+		if r == 60 {
+			c.handleCE(&f, n)
+		}
+		w := Clock(f.cwnd / MSS)
+		for i := Clock(0); i < w; i++ {
+			n.now = Clock(time.Second) + Clock(r)*rtt + (i+1)*rtt/w
+			c.grow(MSS, Packet{}, &f, n)
+		}
+		if w := uint32(f.cwnd / MSS); w != g {
+			t.Fatalf("round %d cwnd %d, want %d", r, w, g)
+		}
+	}
+}
+
+func TestCubicRoot(t *testing.T) {
+	for _, x := range []struct {
+		a    uint64
+		root uint32
+	}{
+		{0, 0},
+		{1, 1},
+		{7, 2},
+		{63, 4},
+		{1000, 10},
+		{12345, 23},
+		{1 << 20, 101},
+		{80452070310, 4316},
+		{804520703100, 9294},
+		{5497558138880, 17664},
+	} {
+		if r := cubicRoot(x.a); r != x.root {
+			t.Errorf("cubicRoot(%d) = %d, want %d", x.a, r, x.root)
+		}
+	}
+	// within 0.2%, or 2 for small roots, due to integer truncation
+	for a := float64(1 << 20); a < 1<<50; a *= 1.1 {
+		r := float64(cubicRoot(uint64(a)))
+		c := math.Cbrt(float64(uint64(a)))
+		if math.Abs(r-c) > max(2, c*0.002) {
+			t.Errorf("cubicRoot(%d) = %.0f, want %.3f", uint64(a), r, c)
+		}
+	}
+}
+
+func TestLinuxHyStartDelay(t *testing.T) {
+	h := NewLinuxHyStart()
+	n := &testNode{}
+	f := NewFlow(0, ECN, NoSCE, h, NoResponse{}, NewLinuxCUBIC(), NoPacing,
+		true)
+	f.cwnd = LinuxHyStartLowWindow * MSS
+	h.init(&f, n)
+	// samples are spaced apart by more than LinuxHyStartAckDelta, so only
+	// delay increase detection applies, with a threshold of 100ms + 12.5ms
+	// for the min RTT of each round, checked after 8 samples
+	for r, d := range []Clock{100, 112, 113} {
+		f.seq = Seq(r + 1)
+		f.receiveNext = Seq(r + 1)
+		for i := 0; i < 9; i++ {
+			n.now += Clock(5 * time.Millisecond)
+			h.updateRtt(d*Clock(time.Millisecond), &f, n)
+			if x := h.grow(0, &f, n); x != (r == 2 && i == 8) {
+				t.Fatalf("round %d sample %d exit %t", r, i, x)
+			}
+		}
+	}
+}
+
+func TestLinuxHyStartAckTrain(t *testing.T) {
+	h := NewLinuxHyStart()
+	n := &testNode{}
+	f := NewFlow(0, ECN, NoSCE, h, NoResponse{}, NewLinuxCUBIC(), NoPacing,
+		true)
+	f.cwnd = LinuxHyStartLowWindow * MSS
+	h.init(&f, n)
+	// without pacing, the ACK train is detected past half the min delay
+	for i := 1; i <= 51; i++ {
+		n.now = Clock(i) * Clock(time.Millisecond)
+		h.updateRtt(Clock(100*time.Millisecond), &f, n)
+		if x := h.grow(0, &f, n); x != (i == 51) {
+			t.Fatalf("ACK at %dms exit %t", i, x)
+		}
+	}
+}
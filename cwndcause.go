@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// cwndCause is the cause of a cwnd change.  The Sender sets the cause before
+// dispatching each event to the slow-start algorithm or CCA, so that changes
+// made through setCWND are attributed to it.
+type cwndCause string
+
+const (
+	causeNone          cwndCause = "none"
+	causeGrowth        cwndCause = "growth"
+	causeCE            cwndCause = "CE"
+	causeSCE           cwndCause = "SCE"
+	causeRateSample    cwndCause = "rate-sample"
+	causeTelemetry     cwndCause = "telemetry"
+	causeRTT           cwndCause = "rtt"
+	causeSlowStartExit cwndCause = "ss-exit"
+	causeProbe         cwndCause = "probe"
+	causeClamp         cwndCause = "clamp"
+)
+
+// cwndCauses lists all causes, in the order they're reported.
+var cwndCauses = []cwndCause{
+	causeGrowth,
+	causeCE,
+	causeSCE,
+	causeRateSample,
+	causeTelemetry,
+	causeRTT,
+	causeSlowStartExit,
+	causeProbe,
+	causeClamp,
+	causeNone,
+}
+
+// cwndCauseStats contains the number of cwnd changes, and the bytes added and
+// removed, for one cause.
+type cwndCauseStats struct {
+	changes int
+	added   Bytes
+	removed Bytes
+}
+
+// add adds a change in cwnd of delta bytes.
+func (s *cwndCauseStats) add(delta Bytes) {
+	s.changes++
+	if delta > 0 {
+		s.added += delta
+	} else {
+		s.removed -= delta
+	}
+}
+
+// cwndAttribution records cwnd changes by cause for a flow, both in total, and
+// optionally as a timeline of per-interval totals written to a file.
+type cwndAttribution struct {
+	total    map[cwndCause]*cwndCauseStats
+	interval map[cwndCause]*cwndCauseStats
+	start    Clock
+	file     *os.File
+	writer   *bufio.Writer
+}
+
+// newCwndAttribution returns a new cwndAttribution.
+func newCwndAttribution() cwndAttribution {
+	return cwndAttribution{
+		make(map[cwndCause]*cwndCauseStats), // total
+		make(map[cwndCause]*cwndCauseStats), // interval
+		0,                                   // start
+		nil,                                 // file
+		nil,                                 // writer
+	}
+}
+
+// Open creates the named timeline file and writes the header.
+func (a *cwndAttribution) Open(name string) (err error) {
+	if a.file, err = os.Create(name); err != nil {
+		return
+	}
+	a.writer = bufio.NewWriter(a.file)
+	_, err = fmt.Fprintln(a.writer, "start\tend\tcause\tchanges\tadded\tremoved")
+	return
+}
+
+// Close writes the last interval, then flushes and closes the timeline file.
+func (a *cwndAttribution) Close(now Clock) (err error) {
+	if a.file == nil {
+		return
+	}
+	a.writeInterval(now)
+	if err = a.writer.Flush(); err != nil {
+		return
+	}
+	err = a.file.Close()
+	a.file = nil
+	return
+}
+
+// add records a change in cwnd of delta bytes for the given cause.
+func (a *cwndAttribution) add(cause cwndCause, delta Bytes, now Clock) {
+	if delta == 0 {
+		return
+	}
+	stats(a.total, cause).add(delta)
+	if a.file == nil {
+		return
+	}
+	if now >= a.start+CwndTimelineInterval {
+		a.writeInterval(a.start + CwndTimelineInterval)
+		a.start = now - (now % CwndTimelineInterval)
+	}
+	stats(a.interval, cause).add(delta)
+}
+
+// writeInterval writes the current interval's stats to the timeline file, up
+// to the given end time, and clears them.
+func (a *cwndAttribution) writeInterval(end Clock) {
+	for _, c := range cwndCauses {
+		if s, ok := a.interval[c]; ok {
+			fmt.Fprintf(a.writer, "%s\t%s\t%s\t%d\t%d\t%d\n",
+				a.start, end, c, s.changes, s.added, s.removed)
+		}
+	}
+	clear(a.interval)
+}
+
+// log logs the total stats for each cause, with the net change in cwnd.
+func (a *cwndAttribution) log(id FlowID, node Node) {
+	for _, c := range cwndCauses {
+		if s, ok := a.total[c]; ok {
+			node.Logf("flow:%d cwnd cause:%s changes:%d added:%d "+
+				"removed:%d net:%d", id, c, s.changes, s.added, s.removed,
+				s.added-s.removed)
+		}
+	}
+}
+
+// stats returns the stats for the given cause, adding them if necessary.
+func stats(m map[cwndCause]*cwndCauseStats, cause cwndCause) *cwndCauseStats {
+	s, ok := m[cause]
+	if !ok {
+		s = &cwndCauseStats{}
+		m[cause] = s
+	}
+	return s
+}
+
+// attributeCwnd attributes a change in cwnd from cwnd0 to the current cause,
+// given the requested cwnd before clamping.  Any bytes added by clamping are
+// attributed to causeClamp.
+func (f *Flow) attributeCwnd(cwnd0, requested Bytes, node Node) {
+	if !CwndAttribution {
+		return
+	}
+	f.cwndCauses.add(f.cause, requested-cwnd0, node.Now())
+	f.cwndCauses.add(causeClamp, f.cwnd-requested, node.Now())
+}
+
+// setCWNDFor sets cwnd, with the change attributed to the given cause instead
+// of the current one.
+func (f *Flow) setCWNDFor(cause cwndCause, cwnd Bytes, node Node) {
+	c := f.cause
+	f.cause = cause
+	f.setCWND(cwnd, node)
+	f.cause = c
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math"
+)
+
+// DCTCP implements the Data Center TCP CCA (RFC 8257).  On CE, cwnd is reduced
+// once per window by half the fraction of marked bytes (alpha), which is
+// maintained by the Flow.  Growth is Reno-linear, one MSS per window of acked
+// bytes.
+type DCTCP struct {
+	growRem Bytes
+}
+
+// NewDCTCP returns a new DCTCP.
+func NewDCTCP() *DCTCP {
+	return &DCTCP{
+		0, // growRem
+	}
+}
+
+// handleCE implements handleCEer.
+func (d *DCTCP) handleCE(flow *Flow, node Node) {
+	alphaMD(flow, node)
+}
+
+// grow implements CCA.
+func (d *DCTCP) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE {
+		return
+	}
+	w := flow.cwnd
+	a := acked*flow.mss() + d.growRem
+	d.growRem = a % w
+	flow.setCWND(w+a/w, node)
+}
+
+// alphaMD reduces cwnd once per window with AlphaMD, by half the fraction of
+// marked bytes (alpha), as in DCTCP.
+func alphaMD(flow *Flow, node Node) {
+	if flow.receiveNext > flow.signalNext {
+		flow.setCWND(AlphaMD{}.Respond(flow, node), node)
+		flow.signalNext = flow.seq
+	}
+}
+
+// Prague implements the TCP Prague CCA for L4S
+// (draft-briscoe-iccrg-prague-congestion-control).  It uses the same response
+// to CE as DCTCP, and for RTT independence, scales its additive increase for
+// RTTs below PragueRTTVirt so that the rate grows as for a flow with that RTT.
+// Prague flows should be configured as L4S capable, and AccECN feedback should
+// be enabled (see AccurateECN).
+type Prague struct {
+	growRem float64
+}
+
+// NewPrague returns a new Prague.
+func NewPrague() *Prague {
+	return &Prague{
+		0, // growRem
+	}
+}
+
+// handleCE implements handleCEer.
+func (p *Prague) handleCE(flow *Flow, node Node) {
+	alphaMD(flow, node)
+}
+
+// grow implements CCA.
+func (p *Prague) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE {
+		return
+	}
+	s := 1.0
+	if flow.srtt < PragueRTTVirt {
+		s = math.Pow(float64(flow.srtt)/float64(PragueRTTVirt), 2)
+	}
+	a := float64(acked)*float64(flow.mss())*s/float64(flow.cwnd) + p.growRem
+	g := math.Floor(a)
+	p.growRem = a - g
+	flow.setCWND(flow.cwnd+Bytes(g), node)
+}
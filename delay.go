@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 type Delay []Clock
 
@@ -17,3 +17,19 @@ func (d Delay) Ding(data any, node Node) error {
 	node.Send(p)
 	return nil
 }
+
+// FixedDelay is a Handler that delays the packets of all flows by the same
+// time, for example on one Path of a Multipath.
+type FixedDelay Clock
+
+// Handle implements Handler.
+func (d FixedDelay) Handle(pkt Packet, node Node) error {
+	node.Timer(Clock(d), pkt)
+	return nil
+}
+
+// Ding implements Dinger.
+func (d FixedDelay) Ding(data any, node Node) error {
+	node.Send(data.(Packet))
+	return nil
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"time"
+)
+
+// rateSample is a delivery rate sample, taken on each ACK according to
+// draft-cheng-iccrg-delivery-rate-estimation.  CCAs and SlowStarts receive it
+// through handleRateSampler and handleRateSampleSSer, and Responders may read
+// it from the Flow.  For app-limited samples, the delivery rate may be below
+// the available bandwidth, so these are normally only used if they increase a
+// bandwidth estimate.
+type rateSample struct {
+	deliveryRate   Bitrate // 0 if the sample is invalid
+	delivered      Bytes   // bytes delivered over the interval
+	deliveredCE    Bytes   // CE marked bytes delivered over the interval
+	priorDelivered Bytes   // delivered when the acked packet was sent
+	interval       Clock
+	rtt            Clock // 0 if the ACK was delayed
+	txInFlight     Bytes // in-flight when the acked packet was sent
+	newlyAcked     Bytes
+	appLimited     bool // true if the acked packet was sent app-limited
+}
+
+// onSendRate records the delivery rate estimation state in the given Packet
+// before it's sent.
+func (f *Flow) onSendRate(pkt *Packet, now Clock) {
+	if f.inFlight == 0 {
+		f.firstSent = now
+		f.deliveredTime = now
+	}
+	pkt.Delivered = f.delivered
+	pkt.DeliveredCE = f.deliveredCE
+	pkt.DeliveredTime = f.deliveredTime
+	pkt.FirstSent = f.firstSent
+	pkt.TxInFlight = f.inFlight + pkt.SegmentLen()
+	pkt.AppLimited = f.appLimited != 0
+}
+
+// setAppLimited marks the flow as app-limited until the data currently
+// in-flight is delivered, so rate samples for packets sent until then are
+// flagged.  This is done when the flow goes inactive, and may be done by CCAs
+// that deliberately limit in-flight, as BBR does in ProbeRTT.
+func (f *Flow) setAppLimited() {
+	f.appLimited = max(f.delivered+f.inFlight, 1)
+}
+
+// sampleRate updates the delivery rate estimation state and takes a rate
+// sample from the given ACK.  The ACK carries the state recorded for the most
+// recently sent packet that it acknowledges.  The marked bytes are the number
+// of newly CE marked bytes indicated by the ACK.
+func (f *Flow) sampleRate(pkt Packet, acked, marked Bytes, now Clock) {
+	f.delivered += acked
+	f.deliveredCE += marked
+	if f.appLimited != 0 && f.delivered > f.appLimited {
+		f.appLimited = 0
+	}
+	f.deliveredTime = now
+	f.firstSent = pkt.Sent
+	s := pkt.Sent - pkt.FirstSent
+	a := now - pkt.DeliveredTime
+	rs := rateSample{
+		0,                               // deliveryRate
+		f.delivered - pkt.Delivered,     // delivered
+		f.deliveredCE - pkt.DeliveredCE, // deliveredCE
+		pkt.Delivered,                   // priorDelivered
+		max(s, a),                       // interval
+		0,                               // rtt
+		pkt.TxInFlight,                  // txInFlight
+		acked,                           // newlyAcked
+		pkt.AppLimited,                  // appLimited
+	}
+	if !pkt.Delayed {
+		rs.rtt = now - pkt.Sent
+	}
+	// discard samples with an interval below min RTT, which may be inflated
+	// by ACK compression
+	if rs.interval >= f.minRtt && rs.interval > 0 {
+		rs.deliveryRate = CalcBitrate(rs.delivered,
+			time.Duration(rs.interval))
+	}
+	f.rs = rs
+}
@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"time"
@@ -13,6 +13,7 @@ type DelticMDS struct {
 	queue []Packet
 	// parameters
 	target Clock
+	mode   ECNMode
 	// calculated values
 	resonance Clock
 	// DelTiC variables
@@ -26,10 +27,11 @@ type DelticMDS struct {
 }
 
 // NewDelticMDS returns a new DelticMDS.
-func NewDelticMDS(target Clock) *DelticMDS {
+func NewDelticMDS(target Clock, mode ECNMode) *DelticMDS {
 	return &DelticMDS{
 		make([]Packet, 0),           // queue
 		target,                      // target
+		mode,                        // mode
 		Clock(time.Second) / target, // resonance
 		0,                           // acc
 		0,                           // mdsOsc
@@ -71,17 +73,7 @@ func (d *DelticMDS) Dequeue(node Node) (pkt Packet, ok bool) {
 	var m mark
 	ok = true
 	if s*2 >= d.target {
-		m = d.oscillate(dt, node, pkt)
-		switch m {
-		case markSCE:
-			pkt.SCE = true
-		case markCE:
-			pkt.CE = true
-		case markDrop:
-			// NOTE sender drop logic doesn't work yet so we do a CE
-			//ok = false
-			pkt.CE = true
-		}
+		m = d.mode.apply(d.oscillate(dt, node, pkt), &pkt)
 	}
 
 	d.priorTime = node.Now()
@@ -163,9 +155,9 @@ func (d *DelticMDS) oscillate(dt Clock, node Node, pkt Packet) mark {
 
 	// assign mark
 	var m mark
-	if pkt.SCECapable {
+	if d.mode.scalable(pkt) {
 		m = s
-	} else if pkt.ECNCapable {
+	} else if pkt.ECN.ECT() {
 		m = c
 	} else if m = c; m == markCE {
 		m = markDrop
@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"time"
@@ -11,10 +11,13 @@ import (
 // drop.
 type Deltic struct {
 	queue []Packet
+	// parameters
+	mode ECNMode
 	// DelTiC instances and variables
 	sce       deltic
 	ce        deltic
 	drop      deltic
+	marker    byteMarker
 	jit       jitterEstimator
 	priorTime Clock
 	// Plots
@@ -22,13 +25,15 @@ type Deltic struct {
 }
 
 // NewDeltic returns a new Deltic.
-func NewDeltic(sceTarget, ceTarget, dropTarget Clock) *Deltic {
+func NewDeltic(sceTarget, ceTarget, dropTarget Clock, mode ECNMode) *Deltic {
 	p := newAqmPlot()
 	return &Deltic{
 		make([]Packet, 0),          // queue
+		mode,                       // mode
 		newDeltic(sceTarget, p),    // sce
 		newDeltic(ceTarget, nil),   // ce
 		newDeltic(dropTarget, nil), // drop
+		newByteMarker(),            // marker
 		jitterEstimator{},          // jit
 		0,                          // priorTime
 		p,                          // aqmPlot
@@ -66,26 +71,20 @@ func (d *Deltic) Dequeue(node Node) (pkt Packet, ok bool) {
 	}
 	dt := node.Now() - d.priorTime
 
-	// run deltic
-	sce := d.sce.control(s, dt, node)
-	ce := d.ce.control(s, dt, node)
-	drop := d.drop.control(s, dt, node)
+	// run deltic, with marks scaled to packet length
+	sce := d.marker.mark(d.sce.control(s, dt, node), pkt.Len)
+	ce := d.marker.mark(d.ce.control(s, dt, node), pkt.Len)
+	drop := d.marker.mark(d.drop.control(s, dt, node), pkt.Len)
 
-	// NOTE sender drop logic doesn't work yet, so we do a blind CE instead
 	ok = true
 	var m mark
 	if drop {
 		//ok = false
-		m = markDrop
-		pkt.CE = true
+		m = d.mode.apply(markDrop, &pkt)
 	} else if ce {
-		m = markCE
-		pkt.CE = true
+		m = d.mode.apply(markCE, &pkt)
 	} else if sce {
-		if pkt.SCECapable {
-			m = markSCE
-			pkt.SCE = true
-		}
+		m = d.mode.apply(markSCE, &pkt)
 	}
 
 	d.priorTime = node.Now()
@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"time"
@@ -14,6 +14,7 @@ type Deltim struct {
 	queue []Packet
 	// parameters
 	burst Clock
+	mode  ECNMode
 	// calculated values
 	resonance Clock
 	// DelTiM variables
@@ -31,10 +32,11 @@ type Deltim struct {
 }
 
 // NewDeltim returns a new Deltim.
-func NewDeltim(burst Clock) *Deltim {
+func NewDeltim(burst Clock, mode ECNMode) *Deltim {
 	return &Deltim{
 		make([]Packet, 0),          // queue
 		burst,                      // burst
+		mode,                       // mode
 		Clock(time.Second) / burst, // resonance
 		0,                          // acc
 		0,                          // mdsOsc
@@ -96,16 +98,7 @@ func (d *Deltim) Dequeue(node Node) (pkt Packet, ok bool) {
 	var m mark
 	ok = true
 	m = d.oscillate(node.Now()-d.priorTime-d.idleTime, node, pkt)
-	switch m {
-	case markSCE:
-		pkt.SCE = true
-	case markCE:
-		pkt.CE = true
-	case markDrop:
-		// NOTE sender drop logic doesn't work yet so we do a CE
-		//ok = false
-		pkt.CE = true
-	}
+	m = d.mode.apply(m, &pkt)
 
 	if len(d.queue) == 0 {
 		d.activeTime = node.Now() - d.activeStart
@@ -197,9 +190,9 @@ func (d *Deltim) oscillate(dt Clock, node Node, pkt Packet) mark {
 
 	// assign mark
 	var m mark
-	if pkt.SCECapable {
+	if d.mode.scalable(pkt) {
 		m = s
-	} else if pkt.ECNCapable {
+	} else if pkt.ECN.ECT() {
 		m = c
 	} else if m = c; m == markCE {
 		m = markDrop
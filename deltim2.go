@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"fmt"
@@ -28,6 +28,7 @@ type Deltim2 struct {
 	// parameters
 	burst  Clock
 	update Clock
+	mode   ECNMode
 	// calculated values
 	resonance Clock
 	// DelTiC variables
@@ -50,11 +51,12 @@ type Deltim2 struct {
 	*aqmPlot
 }
 
-func NewDeltim2(burst, update Clock) *Deltim2 {
+func NewDeltim2(burst, update Clock, mode ECNMode) *Deltim2 {
 	return &Deltim2{
 		make([]Packet, 0),          // queue
 		burst,                      // burst
 		update,                     // update
+		mode,                       // mode
 		Clock(time.Second) / burst, // resonance
 		0,                          // acc
 		0,                          // mdsOsc
@@ -139,16 +141,7 @@ func (d *Deltim2) Dequeue(node Node) (pkt Packet, ok bool) {
 	var m mark
 	ok = true
 	m = d.oscillate(node.Now()-d.priorTime-d.idleTime, node, pkt)
-	switch m {
-	case markSCE:
-		pkt.SCE = true
-	case markCE:
-		pkt.CE = true
-	case markDrop:
-		// NOTE sender drop logic doesn't work yet so we do a CE
-		//ok = false
-		pkt.CE = true
-	}
+	m = d.mode.apply(m, &pkt)
 
 	d.updateActive += node.Now() - d.activeStart
 	d.activeStart = node.Now()
@@ -239,9 +232,9 @@ func (d *Deltim2) oscillate(dt Clock, node Node, pkt Packet) mark {
 
 	// assign mark
 	var m mark
-	if pkt.SCECapable {
+	if d.mode.scalable(pkt) {
 		m = s
-	} else if pkt.ECNCapable {
+	} else if pkt.ECN.ECT() {
 		m = c
 	} else if m = c; m == markCE {
 		m = markDrop
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/rand"
+)
+
+// DualPI2 implements the DualQ Coupled AQM (RFC 9332).  ECT(1) and CE packets
+// are classified into the low-latency (L) queue, and all other packets into
+// the Classic (C) queue.  The queues are served by a time-shifted FIFO
+// scheduler.  The base PI controller runs on the larger of the two queue
+// delays, as in the Linux implementation, and its output p' is squared for the
+// Classic queue, and coupled to the L queue by the coupling factor.  The L
+// queue also has its own native AQM, which marks at a step threshold.
+type DualPI2 struct {
+	lQueue []Packet
+	cQueue []Packet
+	// parameters
+	step Clock
+	// variables
+	pi   pi2
+	rand *rand.Rand
+	// Plots
+	*aqmPlot
+}
+
+// NewDualPI2 returns a new DualPI2, with the given PI target and update
+// interval, and step threshold for the L queue.
+func NewDualPI2(target, tUpdate, step Clock) *DualPI2 {
+	p := newAqmPlot()
+	return &DualPI2{
+		make([]Packet, 0),                 // lQueue
+		make([]Packet, 0),                 // cQueue
+		step,                              // step
+		newPI2(target, tUpdate, p),        // pi
+		rand.New(rand.NewSource(AQMSeed)), // rand
+		p,                                 // aqmPlot
+	}
+}
+
+// Start implements Starter.
+func (d *DualPI2) Start(node Node) error {
+	return d.aqmPlot.Start(node)
+}
+
+// Enqueue implements AQM.
+func (d *DualPI2) Enqueue(pkt Packet, node Node) {
+	d.pi.update(d.qdelay, node)
+	pkt.Enqueue = node.Now()
+	if d.isL4S(pkt) {
+		d.lQueue = append(d.lQueue, pkt)
+	} else {
+		d.cQueue = append(d.cQueue, pkt)
+	}
+	d.plotLength(d.Len(), node.Now())
+}
+
+// isL4S returns true if the Packet should be classified into the L queue.
+func (d *DualPI2) isL4S(pkt Packet) bool {
+	return pkt.ECN == ECT1 || pkt.ECN == CE
+}
+
+// Dequeue implements AQM.
+func (d *DualPI2) Dequeue(node Node) (pkt Packet, ok bool) {
+	d.pi.update(d.qdelay, node)
+	var l bool
+	if l, ok = d.schedule(node.Now()); !ok {
+		return
+	}
+
+	// NOTE sender drop logic doesn't work yet, so we do a blind CE instead
+	var m mark
+	if l {
+		pkt, d.lQueue = d.lQueue[0], d.lQueue[1:]
+		if node.Now()-pkt.Enqueue >= d.step ||
+			d.rand.Float64() < d.pi.scalableProb() {
+			m = markCE
+		}
+	} else {
+		pkt, d.cQueue = d.cQueue[0], d.cQueue[1:]
+		if d.rand.Float64() < d.pi.classicProb() {
+			if !pkt.ECN.ECT() {
+				m = markDrop
+			} else {
+				m = markCE
+			}
+		}
+	}
+	if m != markNone {
+		pkt.ECN = CE
+	}
+
+	d.plotSojourn(node.Now()-pkt.Enqueue, d.Len() == 0, node.Now())
+	d.plotLength(d.Len(), node.Now())
+	d.plotMark(m, node.Now())
+
+	return
+}
+
+// schedule selects the queue to dequeue from using a time-shifted FIFO, and
+// returns true for the L queue.  False is returned for ok if both queues are
+// empty.
+func (d *DualPI2) schedule(now Clock) (l bool, ok bool) {
+	switch {
+	case len(d.lQueue) == 0 && len(d.cQueue) == 0:
+		return
+	case len(d.cQueue) == 0:
+		l = true
+	case len(d.lQueue) == 0:
+		l = false
+	default:
+		ls := now - d.lQueue[0].Enqueue
+		cs := now - d.cQueue[0].Enqueue
+		l = ls+DualPi2TShift >= cs
+	}
+	ok = true
+	return
+}
+
+// qdelay returns the larger of the L and C queue delays at the given time.
+func (d *DualPI2) qdelay(now Clock) (delay Clock) {
+	if len(d.lQueue) > 0 {
+		delay = now - d.lQueue[0].Enqueue
+	}
+	if len(d.cQueue) > 0 {
+		delay = max(delay, now-d.cQueue[0].Enqueue)
+	}
+	return
+}
+
+// Stop implements Stopper.
+func (d *DualPI2) Stop(node Node) error {
+	return d.aqmPlot.Stop(node)
+}
+
+// Peek implements AQM.
+func (d *DualPI2) Peek(node Node) (pkt Packet, ok bool) {
+	var l bool
+	if l, ok = d.schedule(node.Now()); !ok {
+		return
+	}
+	if l {
+		pkt = d.lQueue[0]
+	} else {
+		pkt = d.cQueue[0]
+	}
+	return
+}
+
+// Len implements AQM.
+func (d *DualPI2) Len() int {
+	return len(d.lQueue) + len(d.cQueue)
+}
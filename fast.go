@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+// FAST implements FAST TCP (Jin, Wei and Low, 2004).  Once per RTT, the window
+// is updated as:
+//
+//	w = min(2w, (1-FASTGamma)w + FASTGamma(baseRTT/avgRTT*w + FASTAlpha))
+//
+// where baseRTT is the min RTT and avgRTT is the smoothed RTT, so in
+// equilibrium, FASTAlpha packets are kept in the bottleneck queue.  FAST needs
+// no separate slow-start, so FAST flows should use NoSS.  As there is no loss,
+// CE is treated as loss.
+type FAST struct {
+	updateNext Seq
+}
+
+// NewFAST returns a new FAST.
+func NewFAST() *FAST {
+	return &FAST{
+		0, // updateNext
+	}
+}
+
+// handleCE implements handleCEer.
+func (f *FAST) handleCE(flow *Flow, node Node) {
+	if flow.receiveNext > flow.signalNext {
+		flow.setCWND(Bytes(float64(flow.cwnd)*CEMD), node)
+		flow.signalNext = flow.seq
+		f.updateNext = flow.seq
+	}
+}
+
+// grow implements CCA.
+func (f *FAST) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE || flow.receiveNext <= f.updateNext || flow.srtt == 0 {
+		return
+	}
+	f.updateNext = flow.seq
+	w := float64(flow.cwnd)
+	r := float64(flow.minRtt) / float64(flow.srtt)
+	t := (1-FASTGamma)*w + FASTGamma*(r*w+FASTAlpha*float64(flow.mss()))
+	flow.setCWND(Bytes(min(2*w, t)), node)
+}
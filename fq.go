@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+// FQ implements flow queuing, in which packets are hashed by FlowID into
+// per-flow sub-queues, each of which runs its own instance of an AQM.  The
+// sub-queues are served using Deficit Round Robin, with a new flows list that
+// gives priority to sparse flows, as in FQ-CoDel (RFC 8290).
+//
+// The sub-queue AQMs are not started, so their own plots are disabled.  FQ
+// plots the sojourn time, queue length and marks for all sub-queues combined.
+type FQ struct {
+	flow     []fqFlow
+	newAQM   func() AQM
+	quantum  Bytes
+	maxLen   Bytes // largest packet length seen
+	newFlows []*fqFlow
+	oldFlows []*fqFlow
+	next     *fqFlow
+	length   int
+	// Plots
+	*aqmPlot
+}
+
+// fqFlow is a single flow sub-queue.
+type fqFlow struct {
+	aqm     AQM
+	deficit Bytes
+	active  bool // true if on the new or old flows list
+}
+
+// NewFQ returns a new FQ with the given number of sub-queues and DRR quantum.
+// A quantum of 0 uses the largest packet length seen, so it follows the flows'
+// MTUs.  The newAQM function is called to create the AQM for each sub-queue
+// when it's first used.
+func NewFQ(flows int, quantum Bytes, newAQM func() AQM) *FQ {
+	return &FQ{
+		make([]fqFlow, flows), // flow
+		newAQM,                // newAQM
+		quantum,               // quantum
+		0,                     // maxLen
+		nil,                   // newFlows
+		nil,                   // oldFlows
+		nil,                   // next
+		0,                     // length
+		newAqmPlot(),          // aqmPlot
+	}
+}
+
+// Start implements Starter.
+func (q *FQ) Start(node Node) error {
+	return q.aqmPlot.Start(node)
+}
+
+// Enqueue implements AQM.
+func (q *FQ) Enqueue(pkt Packet, node Node) {
+	f := &q.flow[int(pkt.Flow)%len(q.flow)]
+	if f.aqm == nil {
+		f.aqm = q.newAQM()
+	}
+	pkt.Enqueue = node.Now()
+	f.aqm.Enqueue(pkt, node)
+	q.length++
+	q.maxLen = max(q.maxLen, pkt.Len)
+	if !f.active {
+		f.active = true
+		f.deficit = q.flowQuantum()
+		q.newFlows = append(q.newFlows, f)
+	}
+	q.plotLength(q.length, node.Now())
+}
+
+// Dequeue implements AQM.
+func (q *FQ) Dequeue(node Node) (pkt Packet, ok bool) {
+	f := q.next
+	q.next = nil
+	for {
+		if f == nil || f.aqm.Len() == 0 {
+			if f = q.head(); f == nil {
+				return
+			}
+		}
+		p0, _ := f.aqm.Peek(node)
+		if pkt, ok = f.aqm.Dequeue(node); ok {
+			q.length--
+			f.deficit -= pkt.Len
+			q.plotSojourn(node.Now()-pkt.Enqueue, q.length == 0, node.Now())
+			q.plotLength(q.length, node.Now())
+			q.plotMark(fqMark(p0, pkt), node.Now())
+			return
+		}
+		f = nil
+	}
+}
+
+// head returns the sub-queue to be served next, advancing the DRR state as
+// necessary, or nil if all sub-queues are empty.
+func (q *FQ) head() *fqFlow {
+	for {
+		var l *[]*fqFlow
+		switch {
+		case len(q.newFlows) > 0:
+			l = &q.newFlows
+		case len(q.oldFlows) > 0:
+			l = &q.oldFlows
+		default:
+			return nil
+		}
+		f := (*l)[0]
+		// replenish deficit and move to the end of the old flows list
+		if f.deficit <= 0 {
+			f.deficit += q.flowQuantum()
+			*l = (*l)[1:]
+			q.oldFlows = append(q.oldFlows, f)
+			continue
+		}
+		// remove empty flows, moving new flows to the old flows list first, if
+		// it's not empty, to prevent starvation
+		if f.aqm.Len() == 0 {
+			*l = (*l)[1:]
+			if l == &q.newFlows && len(q.oldFlows) > 0 {
+				q.oldFlows = append(q.oldFlows, f)
+			} else {
+				f.active = false
+			}
+			continue
+		}
+		return f
+	}
+}
+
+// flowQuantum returns the DRR quantum, or the largest packet length seen if
+// the quantum is 0.
+func (q *FQ) flowQuantum() Bytes {
+	if q.quantum > 0 {
+		return q.quantum
+	}
+	return q.maxLen
+}
+
+// fqMark returns the mark applied by a sub-queue AQM, by comparing the head
+// packet before dequeue with the dequeued packet.
+func fqMark(before, after Packet) mark {
+	switch {
+	case after.ECN == CE && before.ECN != CE:
+		if before.ECN == NotECT {
+			return markDrop
+		}
+		return markCE
+	case after.ECN == ECT1 && before.ECN == ECT0:
+		return markSCE
+	}
+	return markNone
+}
+
+// Stop implements Stopper.
+func (q *FQ) Stop(node Node) error {
+	return q.aqmPlot.Stop(node)
+}
+
+// Peek implements AQM.  The sub-queue to be served next is selected, so that
+// the next call to Dequeue returns a packet from the same sub-queue.
+func (q *FQ) Peek(node Node) (pkt Packet, ok bool) {
+	if q.next == nil || q.next.aqm.Len() == 0 {
+		if q.next = q.head(); q.next == nil {
+			return
+		}
+	}
+	return q.next.aqm.Peek(node)
+}
+
+// Len implements AQM.
+func (q *FQ) Len() int {
+	return q.length
+}
@@ -0,0 +1,363 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"slices"
+)
+
+// A HostQdisc is a queueing discipline on the sending host, between the
+// Sender's flows and the path.  Unlike an AQM, it never drops or marks, but
+// refuses packets when full, which the flows see as backpressure.
+type HostQdisc interface {
+	// enqueue adds a packet from the given flow.
+	enqueue(Packet, *Flow, Node)
+	// dequeue returns the next packet to send.  If no packet may be sent
+	// now, ok is false, and wait is the time until one may be sent, or 0 if
+	// the qdisc is empty.
+	dequeue(Node) (pkt Packet, ok bool, wait Clock)
+	// full returns true if the qdisc can't accept a packet from the flow.
+	full(FlowID) bool
+	// len returns the number of packets in the qdisc.
+	len() int
+	// paces returns true if the qdisc paces packets, so flows needn't.
+	paces() bool
+}
+
+// hostQueue is the host queue between a Sender's flows and the path.  Packets
+// are dequeued from the HostQdisc and sent at the host's NIC rate, and flows
+// are woken on transmit completion, as for TSQ.
+type hostQueue struct {
+	qdisc     HostQdisc
+	rate      Bitrate
+	flow      []Flow
+	sending   bool     // true while a packet is being transmitted
+	wake      Clock    // time of the pending pacing timer, or 0 if none
+	refused   []FlowID // flows refused because the qdisc was full
+	maxLen    int
+	throttled int // number of times flows were throttled by TSQ
+}
+
+// hostTransmit is used as timer data for the host queue.  If Len is nonzero,
+// the timer is for the completion of a transmit, otherwise it's for pacing.
+type hostTransmit struct {
+	Flow FlowID
+	Len  Bytes
+}
+
+// newHostQueue returns a new hostQueue.
+func newHostQueue(qdisc HostQdisc, rate Bitrate, flow []Flow) *hostQueue {
+	return &hostQueue{
+		qdisc, // qdisc
+		rate,  // rate
+		flow,  // flow
+		false, // sending
+		0,     // wake
+		nil,   // refused
+		0,     // maxLen
+		0,     // throttled
+	}
+}
+
+// paces returns true if there is a host queue, and its qdisc paces packets.
+func (h *hostQueue) paces() bool {
+	return h != nil && h.qdisc.paces()
+}
+
+// throttle returns true if the flow may not send now, either because it's
+// reached its TSQ limit, or the qdisc is full.
+func (h *hostQueue) throttle(flow *Flow) bool {
+	if HostTSQ && flow.hostQueued > flow.tsqLimit() {
+		h.throttled++
+		return true
+	}
+	if h.qdisc.full(flow.id) {
+		if !flow.hostRefused {
+			flow.hostRefused = true
+			h.refused = append(h.refused, flow.id)
+		}
+		return true
+	}
+	return false
+}
+
+// enqueue adds a packet from the given flow, and starts transmitting if idle.
+func (h *hostQueue) enqueue(pkt Packet, flow *Flow, node Node) {
+	h.qdisc.enqueue(pkt, flow, node)
+	flow.hostQueued += pkt.Len
+	h.maxLen = max(h.maxLen, h.qdisc.len())
+	h.transmit(node)
+}
+
+// transmit sends the next packet, if not already transmitting and one may be
+// sent, and starts a timer for its transmit completion.  If no packet may be
+// sent until later, it starts a pacing timer, unless an earlier one is pending.
+func (h *hostQueue) transmit(node Node) {
+	if h.sending {
+		return
+	}
+	p, ok, w := h.qdisc.dequeue(node)
+	if !ok {
+		if w > 0 && (h.wake == 0 || node.Now()+w < h.wake) {
+			h.wake = node.Now() + w
+			node.Timer(w, hostTransmit{})
+		}
+		return
+	}
+	h.sending = true
+	node.Send(p)
+	t := Clock(TransferTime(h.rate, p.Len))
+	node.Timer(t, hostTransmit{p.Flow, p.Len})
+}
+
+// ding handles a host queue timer.  On transmit completion, the flow's bytes
+// are released, and it's woken so it may send again, along with the flows that
+// were refused because the qdisc was full, which may be refused again and
+// re-added.  Superseded pacing timers are ignored.
+func (h *hostQueue) ding(t hostTransmit, node Node) {
+	if t.Len == 0 {
+		if node.Now() != h.wake {
+			return
+		}
+		h.wake = 0
+	} else {
+		h.sending = false
+		f := &h.flow[t.Flow]
+		f.hostQueued -= t.Len
+		r := h.refused
+		h.refused = nil
+		if !f.hostRefused {
+			r = append(r, t.Flow)
+		}
+		slices.Sort(r)
+		for _, i := range r {
+			h.flow[i].hostRefused = false
+		}
+		for _, i := range r {
+			h.flow[i].send(node)
+		}
+	}
+	h.transmit(node)
+}
+
+// log logs the host queue stats.
+func (h *hostQueue) log(node Node) {
+	node.Logf("host qdisc %T max-len:%d tsq-throttled:%d", h.qdisc, h.maxLen,
+		h.throttled)
+}
+
+// tsqLimit returns the limit on the flow's bytes in the host queue, as in
+// Linux's tcp_small_queue_check, which is about PacingTSOAutosizeTime at the
+// pacing rate, and at least two packets.
+func (f *Flow) tsqLimit() Bytes {
+	d := f.pacingDelay(f.mtu)
+	if d == 0 {
+		return TSQLimitBytes
+	}
+	return min(max(2*f.mtu, f.mtu*Bytes(PacingTSOAutosizeTime)/Bytes(d)),
+		TSQLimitBytes)
+}
+
+// PfifoFast is a HostQdisc that approximates Linux's pfifo_fast, with all
+// packets in one band, so it's a FIFO with a limit in packets (txqueuelen).
+type PfifoFast struct {
+	queue []Packet
+	limit int
+}
+
+// NewPfifoFast returns a new PfifoFast with the given limit in packets.
+func NewPfifoFast(limit int) *PfifoFast {
+	return &PfifoFast{
+		nil,   // queue
+		limit, // limit
+	}
+}
+
+// enqueue implements HostQdisc.
+func (q *PfifoFast) enqueue(pkt Packet, flow *Flow, node Node) {
+	q.queue = append(q.queue, pkt)
+}
+
+// dequeue implements HostQdisc.
+func (q *PfifoFast) dequeue(node Node) (pkt Packet, ok bool, wait Clock) {
+	if len(q.queue) == 0 {
+		return
+	}
+	pkt = q.queue[0]
+	q.queue = q.queue[1:]
+	ok = true
+	return
+}
+
+// full implements HostQdisc.
+func (q *PfifoFast) full(FlowID) bool {
+	return len(q.queue) >= q.limit
+}
+
+// len implements HostQdisc.
+func (q *PfifoFast) len() int {
+	return len(q.queue)
+}
+
+// paces implements HostQdisc.
+func (q *PfifoFast) paces() bool {
+	return false
+}
+
+// HostFQ is a HostQdisc that approximates Linux's sch_fq.  Flows are served
+// with Deficit Round Robin, with a new flows list, and the packets of flows
+// with pacing enabled are paced at the flow's pacing rate, so flows don't pace
+// themselves.  A flow whose next packet isn't due yet is throttled, and
+// returned to the old flows list when it is.
+type HostFQ struct {
+	flow           map[FlowID]*hostFQFlow
+	newFlows       []*hostFQFlow
+	oldFlows       []*hostFQFlow
+	throttled      []*hostFQFlow
+	quantum        Bytes
+	initialQuantum Bytes
+	flowLimit      int
+	limit          int
+	length         int
+}
+
+// hostFQFlow is a single HostFQ flow queue.
+type hostFQFlow struct {
+	flow     *Flow
+	queue    []Packet
+	credit   Bytes
+	timeNext Clock // time the next packet may be sent, for pacing
+	active   bool  // true if on the new, old or throttled flows list
+}
+
+// NewHostFQ returns a new HostFQ with the given quantum and initial quantum,
+// and per-flow and total limits in packets.
+func NewHostFQ(quantum, initialQuantum Bytes, flowLimit, limit int) *HostFQ {
+	return &HostFQ{
+		make(map[FlowID]*hostFQFlow), // flow
+		nil,                          // newFlows
+		nil,                          // oldFlows
+		nil,                          // throttled
+		quantum,                      // quantum
+		initialQuantum,               // initialQuantum
+		flowLimit,                    // flowLimit
+		limit,                        // limit
+		0,                            // length
+	}
+}
+
+// enqueue implements HostQdisc.
+func (q *HostFQ) enqueue(pkt Packet, flow *Flow, node Node) {
+	f, ok := q.flow[pkt.Flow]
+	if !ok {
+		f = &hostFQFlow{flow, nil, 0, 0, false}
+		q.flow[pkt.Flow] = f
+	}
+	f.queue = append(f.queue, pkt)
+	q.length++
+	if !f.active {
+		f.active = true
+		f.credit = q.initialQuantum
+		q.newFlows = append(q.newFlows, f)
+	}
+}
+
+// dequeue implements HostQdisc.
+func (q *HostFQ) dequeue(node Node) (pkt Packet, ok bool, wait Clock) {
+	q.unthrottle(node.Now())
+	f := q.head(node.Now())
+	if f == nil {
+		if len(q.throttled) > 0 {
+			wait = q.throttled[0].timeNext - node.Now()
+			for _, t := range q.throttled[1:] {
+				wait = min(wait, t.timeNext-node.Now())
+			}
+		}
+		return
+	}
+	pkt = f.queue[0]
+	f.queue = f.queue[1:]
+	q.length--
+	f.credit -= pkt.Len
+	if f.flow.pacing.Enabled {
+		f.timeNext = node.Now() + f.flow.pacingDelay(pkt.Len)
+	}
+	ok = true
+	return
+}
+
+// unthrottle moves throttled flows whose next packet is due to the old flows
+// list.
+func (q *HostFQ) unthrottle(now Clock) {
+	t := q.throttled[:0]
+	for _, f := range q.throttled {
+		if f.timeNext <= now {
+			q.oldFlows = append(q.oldFlows, f)
+		} else {
+			t = append(t, f)
+		}
+	}
+	q.throttled = t
+}
+
+// head returns the flow to be served next, advancing the DRR state and
+// throttling flows as necessary, or nil if no flow may be served now.
+func (q *HostFQ) head(now Clock) *hostFQFlow {
+	for {
+		var l *[]*hostFQFlow
+		switch {
+		case len(q.newFlows) > 0:
+			l = &q.newFlows
+		case len(q.oldFlows) > 0:
+			l = &q.oldFlows
+		default:
+			return nil
+		}
+		f := (*l)[0]
+		// replenish credit and move to the end of the old flows list
+		if f.credit <= 0 {
+			f.credit += q.quantum
+			*l = (*l)[1:]
+			q.oldFlows = append(q.oldFlows, f)
+			continue
+		}
+		// remove empty flows, moving new flows to the old flows list first, if
+		// it's not empty, to prevent starvation
+		if len(f.queue) == 0 {
+			*l = (*l)[1:]
+			if l == &q.newFlows && len(q.oldFlows) > 0 {
+				q.oldFlows = append(q.oldFlows, f)
+			} else {
+				f.active = false
+			}
+			continue
+		}
+		// throttle flows whose next packet isn't due yet
+		if f.timeNext > now {
+			*l = (*l)[1:]
+			q.throttled = append(q.throttled, f)
+			continue
+		}
+		return f
+	}
+}
+
+// full implements HostQdisc.
+func (q *HostFQ) full(flow FlowID) bool {
+	if q.length >= q.limit {
+		return true
+	}
+	f, ok := q.flow[flow]
+	return ok && len(f.queue) >= q.flowLimit
+}
+
+// len implements HostQdisc.
+func (q *HostFQ) len() int {
+	return q.length
+}
+
+// paces implements HostQdisc.
+func (q *HostFQ) paces() bool {
+	return true
+}
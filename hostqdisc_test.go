@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"testing"
+	"time"
+)
+
+// testHostFlows returns Flows for the host qdisc tests, with the given pacing
+// options.
+func testHostFlows(pacing ...PacingOptions) []Flow {
+	var f []Flow
+	for i, p := range pacing {
+		f = append(f, NewFlow(FlowID(i), ECN, NoSCE, NoSS{}, NoResponse{},
+			NewReno(RMD, DefaultMDScaling), p, true))
+	}
+	return f
+}
+
+// testDequeue dequeues from the HostQdisc, and returns the flows of the
+// packets dequeued, until none may be sent.
+func testDequeue(q HostQdisc, n Node) (flow []FlowID) {
+	for {
+		p, ok, _ := q.dequeue(n)
+		if !ok {
+			return
+		}
+		flow = append(flow, p.Flow)
+	}
+}
+
+func TestHostFQDRR(t *testing.T) {
+	f := testHostFlows(NoPacing, NoPacing, NoPacing)
+	n := &testNode{}
+	q := NewHostFQ(2000, 2000, 100, 1000)
+	for i := 0; i < 4; i++ {
+		q.enqueue(Packet{Flow: 0, Len: 1000}, &f[0], n)
+	}
+	for i := 0; i < 4; i++ {
+		q.enqueue(Packet{Flow: 1, Len: 1000}, &f[1], n)
+	}
+	if l := q.len(); l != 8 {
+		t.Fatalf("len %d after enqueue, want 8", l)
+	}
+	// each flow sends a quantum per round
+	var g []FlowID
+	for i := 0; i < 4; i++ {
+		p, ok, _ := q.dequeue(n)
+		if !ok {
+			t.Fatalf("dequeue %d not ok", i)
+		}
+		g = append(g, p.Flow)
+	}
+	// a new flow is served before the old flows
+	q.enqueue(Packet{Flow: 2, Len: 1000}, &f[2], n)
+	g = append(g, testDequeue(q, n)...)
+	w := []FlowID{0, 0, 1, 1, 2, 0, 0, 1, 1}
+	if len(g) != len(w) {
+		t.Fatalf("dequeued flows %v, want %v", g, w)
+	}
+	for i := range w {
+		if g[i] != w[i] {
+			t.Fatalf("dequeued flows %v, want %v", g, w)
+		}
+	}
+	if l := q.len(); l != 0 {
+		t.Fatalf("len %d after dequeue, want 0", l)
+	}
+	if len(q.newFlows) != 0 || len(q.oldFlows) != 0 {
+		t.Fatalf("new flows %d old flows %d after dequeue, want 0",
+			len(q.newFlows), len(q.oldFlows))
+	}
+	// empty flows are removed from the lists, and are new again
+	q.enqueue(Packet{Flow: 0, Len: 1000}, &f[0], n)
+	if len(q.newFlows) != 1 || q.newFlows[0].flow != &f[0] {
+		t.Fatalf("flow 0 not on new flows list after going idle")
+	}
+}
+
+func TestHostFQThrottle(t *testing.T) {
+	f := testHostFlows(Pacing, NoPacing)
+	f[0].pacingRate = 8 * Mbps
+	d := Clock(time.Millisecond) // 1000 bytes at 8 Mbps
+	n := &testNode{}
+	q := NewHostFQ(2000, 2000, 100, 1000)
+	for i := 0; i < 2; i++ {
+		q.enqueue(Packet{Flow: 0, Len: 1000}, &f[0], n)
+		q.enqueue(Packet{Flow: 1, Len: 1000}, &f[1], n)
+	}
+	// flow 0 is throttled after its first packet, while flow 1 is served
+	g := testDequeue(q, n)
+	if len(g) != 3 || g[0] != 0 || g[1] != 1 || g[2] != 1 {
+		t.Fatalf("dequeued flows %v, want [0 1 1]", g)
+	}
+	if len(q.throttled) != 1 || q.throttled[0].flow != &f[0] {
+		t.Fatalf("flow 0 not on throttled list")
+	}
+	if _, ok, w := q.dequeue(n); ok || w != d {
+		t.Fatalf("dequeue while throttled ok:%t wait:%d, want false, %d",
+			ok, w, d)
+	}
+	// flow 0 is unthrottled when its next packet is due
+	n.now = d / 2
+	if _, ok, w := q.dequeue(n); ok || w != d/2 {
+		t.Fatalf("dequeue before due ok:%t wait:%d, want false, %d",
+			ok, w, d/2)
+	}
+	n.now = d
+	p, ok, _ := q.dequeue(n)
+	if !ok || p.Flow != 0 {
+		t.Fatalf("dequeue when due ok:%t flow:%d, want true, 0", ok, p.Flow)
+	}
+	if len(q.throttled) != 0 {
+		t.Fatalf("throttled list length %d, want 0", len(q.throttled))
+	}
+	if _, ok, w := q.dequeue(n); ok || w != 0 {
+		t.Fatalf("dequeue when empty ok:%t wait:%d, want false, 0", ok, w)
+	}
+}
+
+func TestHostFQFull(t *testing.T) {
+	f := testHostFlows(NoPacing, NoPacing)
+	n := &testNode{}
+	q := NewHostFQ(2000, 2000, 2, 3)
+	q.enqueue(Packet{Flow: 0, Len: 1000}, &f[0], n)
+	q.enqueue(Packet{Flow: 0, Len: 1000}, &f[0], n)
+	if !q.full(0) {
+		t.Fatalf("flow 0 not full at flow limit")
+	}
+	if q.full(1) {
+		t.Fatalf("flow 1 full below limits")
+	}
+	q.enqueue(Packet{Flow: 1, Len: 1000}, &f[1], n)
+	if !q.full(1) {
+		t.Fatalf("flow 1 not full at limit")
+	}
+}
+
+func TestHostQueueRefused(t *testing.T) {
+	f := testHostFlows(NoPacing, NoPacing, NoPacing)
+	n := &testNode{}
+	h := newHostQueue(NewPfifoFast(1), 100*Mbps, f)
+	h.qdisc.enqueue(Packet{Flow: 0, Len: 1000}, &f[0], n)
+	for _, i := range []FlowID{2, 1, 2} {
+		if !h.throttle(&f[i]) {
+			t.Fatalf("flow %d not throttled with qdisc full", i)
+		}
+	}
+	if len(h.refused) != 2 || h.refused[0] != 2 || h.refused[1] != 1 {
+		t.Fatalf("refused flows %v, want [2 1]", h.refused)
+	}
+	if f[0].hostRefused || !f[1].hostRefused || !f[2].hostRefused {
+		t.Fatalf("hostRefused %t %t %t, want false true true",
+			f[0].hostRefused, f[1].hostRefused, f[2].hostRefused)
+	}
+}
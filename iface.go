@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import "fmt"
 
@@ -19,14 +19,6 @@ type RateAt struct {
 	Rate Bitrate
 }
 
-// An AQM implements Active Queue Management.
-type AQM interface {
-	Enqueue(Packet, Node)
-	Dequeue(Node) (pkt Packet, ok bool)
-	Peek(Node) (pkt Packet, ok bool)
-	Len() int
-}
-
 // NewIface returns a new Iface.
 func NewIface(rate Bitrate, schedule []RateAt, aqm AQM) *Iface {
 	return &Iface{
@@ -37,10 +29,26 @@ func NewIface(rate Bitrate, schedule []RateAt, aqm AQM) *Iface {
 	}
 }
 
+// aqmDing is used as timer data for timers started by the AQM.
+type aqmDing struct {
+	data any
+}
+
+// aqmNode is the Node given to the AQM.  Its timers are tagged, so they're
+// passed to the AQM's Ding, and not taken as the end of a transmit.
+type aqmNode struct {
+	Node
+}
+
+// Timer implements Node.
+func (n aqmNode) Timer(delay Clock, data any) {
+	n.Node.Timer(delay, aqmDing{data})
+}
+
 // Start implements Starter.
 func (i *Iface) Start(node Node) (err error) {
 	if s, ok := i.aqm.(Starter); ok {
-		if err = s.Start(node); err != nil {
+		if err = s.Start(aqmNode{node}); err != nil {
 			return
 		}
 	}
@@ -56,7 +64,7 @@ func (i *Iface) Handle(pkt Packet, node Node) error {
 		panic(fmt.Sprintf("%T reached hard max queue length of %d",
 			i.aqm, i.aqm.Len()))
 	}
-	i.aqm.Enqueue(pkt, node)
+	i.aqm.Enqueue(pkt, aqmNode{node})
 	if i.empty {
 		i.empty = false
 		i.timer(node, pkt)
@@ -66,20 +74,28 @@ func (i *Iface) Handle(pkt Packet, node Node) error {
 
 // Ding implements Dinger.
 func (i *Iface) Ding(data any, node Node) error {
-	// first handle Bitrate
-	if r, ok := data.(Bitrate); ok {
-		i.rate = r
+	// first handle Bitrate and AQM timers
+	switch v := data.(type) {
+	case Bitrate:
+		i.rate = v
 		return nil
+	case aqmDing:
+		d, ok := i.aqm.(Dinger)
+		if !ok {
+			return fmt.Errorf("iface: %T called Timer so must implement Dinger",
+				i.aqm)
+		}
+		return d.Ding(v.data, aqmNode{node})
 	}
-	// if not a Bitrate, dequeue and send if a Packet is available
+	// otherwise, dequeue and send if a Packet is available
 	var p, n Packet
 	var ok bool
-	if p, ok = i.aqm.Dequeue(node); !ok {
+	if p, ok = i.aqm.Dequeue(aqmNode{node}); !ok {
 		i.empty = true
 		return nil
 	}
 	node.Send(p)
-	if n, ok = i.aqm.Peek(node); ok {
+	if n, ok = i.aqm.Peek(aqmNode{node}); ok {
 		i.timer(node, n)
 	} else {
 		i.empty = true
@@ -96,7 +112,7 @@ func (i *Iface) timer(node Node, pkt Packet) {
 // Stop implements Stopper.
 func (i *Iface) Stop(node Node) (err error) {
 	if s, ok := i.aqm.(Stopper); ok {
-		if err = s.Stop(node); err != nil {
+		if err = s.Stop(aqmNode{node}); err != nil {
 			return
 		}
 	}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"time"
+)
+
+// LEDBAT implements the LEDBAT scavenger CCA (RFC 6817).  On each ACK, cwnd is
+// increased or decreased in proportion to how far the queuing delay is from
+// LEDBATTarget.  The RFC caps cwnd at the flight size plus ALLOWED_INCREASE,
+// but since in-flight stays below cwnd with pacing, cwnd is instead not
+// increased for app-limited rate samples.  The RFC uses one-way delay, but as
+// there is no queueing on the return path, the RTT is used instead, so the
+// base delay is the min RTT over a history of one-minute intervals, and the
+// current delay the min of the last LEDBATCurrentFilter RTT samples.  LEDBAT
+// does no slow-start, so LEDBAT flows should use NoSS.  As there is no loss,
+// CE is treated as loss.
+type LEDBAT struct {
+	base        []Clock // base delay history, one per minute
+	baseStart   Clock   // start time of the current base delay interval
+	current     []Clock // current delay filter
+	currentNext int
+	growRem     float64
+}
+
+// NewLEDBAT returns a new LEDBAT.
+func NewLEDBAT() *LEDBAT {
+	return &LEDBAT{
+		[]Clock{ClockMax},                     // base
+		0,                                     // baseStart
+		make([]Clock, 0, LEDBATCurrentFilter), // current
+		0,                                     // currentNext
+		0,                                     // growRem
+	}
+}
+
+// handleCE implements handleCEer.
+func (l *LEDBAT) handleCE(flow *Flow, node Node) {
+	if flow.receiveNext > flow.signalNext {
+		flow.setCWND(max(flow.cwnd/2, LEDBATMinCwnd*flow.mss()), node)
+		flow.signalNext = flow.seq
+	}
+}
+
+// updateRtt implements updateRtter.
+func (l *LEDBAT) updateRtt(rtt Clock, flow *Flow, node Node) {
+	// base delay
+	if node.Now()-l.baseStart >= Clock(time.Minute) {
+		l.baseStart = node.Now()
+		if len(l.base) >= LEDBATBaseHistory {
+			l.base = l.base[1:]
+		}
+		l.base = append(l.base, rtt)
+	} else {
+		l.base[len(l.base)-1] = min(l.base[len(l.base)-1], rtt)
+	}
+	// current delay
+	if len(l.current) < LEDBATCurrentFilter {
+		l.current = append(l.current, rtt)
+	} else {
+		l.current[l.currentNext] = rtt
+		l.currentNext = (l.currentNext + 1) % LEDBATCurrentFilter
+	}
+}
+
+// queuingDelay returns the current queuing delay estimate.
+func (l *LEDBAT) queuingDelay() Clock {
+	b := ClockMax
+	for _, d := range l.base {
+		b = min(b, d)
+	}
+	c := ClockMax
+	for _, d := range l.current {
+		c = min(c, d)
+	}
+	return c - b
+}
+
+// grow implements CCA.
+func (l *LEDBAT) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE || len(l.current) == 0 {
+		return
+	}
+	o := float64(LEDBATTarget-l.queuingDelay()) / float64(LEDBATTarget)
+	a := LEDBATGain*o*float64(acked)*float64(flow.mss())/float64(flow.cwnd) +
+		l.growRem
+	g := Bytes(a)
+	l.growRem = a - float64(g)
+	if g > 0 && flow.rs.appLimited {
+		return
+	}
+	flow.setCWND(max(flow.cwnd+g, LEDBATMinCwnd*flow.mss()), node)
+}
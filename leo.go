@@ -1,19 +1,14 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
-
-import (
-	"math"
-)
+package scim
 
 // LeoStageMax is the maximum number of ESSP and Maslo stages.
 const LeoStageMax = 44
 
 var (
-	LeoK       [LeoStageMax*2 - 1]int // K for each stage (n+1 Leonardo numbers)
-	EsspScale  [LeoStageMax]float64   // scale factors for each ESSP stage
-	MasloSCEMD [LeoStageMax]float64   // Maslo SCE MD for each stage
+	LeoK      [LeoStageMax*2 - 1]int // K for each stage (n+1 Leonardo numbers)
+	EsspScale [LeoStageMax]float64   // scale factors for each ESSP stage
 )
 
 func init() {
@@ -27,10 +22,7 @@ func init() {
 	}
 	for i := 0; i < len(EsspScale); i++ {
 		EsspScale[i] = s
-		x := 1.0 + 1.0/float64(LeoK[i])
-		MasloSCEMD[i] = 1.0 / math.Pow(x, 1.0/MasloM)
-		s /= x
-		//fmt.Printf("%d %d %d %.10f %.10f\n",
-		//	i, LeoK[i], LeoK[i*2], EsspScale[i], MasloSCEMD[i])
+		s /= 1.0 + 1.0/float64(LeoK[i])
+		//fmt.Printf("%d %d %d %.10f\n", i, LeoK[i], LeoK[i*2], EsspScale[i])
 	}
 }
@@ -1,17 +1,32 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
+	"flag"
 	"log"
 	"os"
 	"runtime"
 	"runtime/pprof"
+
+	"github.com/heistp/scim/plot"
 )
 
-func main() {
+// Main runs scim with the settings in config.go.  A program in another module
+// may import this package, change the configuration variables (e.g. Flows and
+// UseAQM) to use its own CCAs and AQMs, then call Main.
+func Main() {
 	log.SetFlags(0)
+	plot.Duration = Duration
+	t := flag.String("trace", Trace.String(),
+		"trace event categories (cwnd,ss-exit,stage,signal-ignored,probe,"+
+			"state or all)")
+	flag.Parse()
+	var err error
+	if Trace, err = ParseTraceCategories(*t); err != nil {
+		log.Fatal(err)
+	}
 	if ProfileCPU {
 		var f *os.File
 		var e error
@@ -21,14 +36,36 @@ func main() {
 		pprof.StartCPUProfile(f)
 		defer pprof.StopCPUProfile()
 	}
-	h := []Handler{
-		NewSender(FlowSchedule),
-		NewIface(RateInit, RateSchedule, UseAQM),
-		Delay(FlowDelay),
-		NewReceiver(),
+	h := []Handler{NewSender(FlowSchedule)}
+	if UpstreamRewriter != nil {
+		h = append(h, UpstreamRewriter)
+	}
+	if TunnelEncap != nil {
+		h = append(h, TunnelEncap)
+	}
+	if UseMultipath != nil {
+		h = append(h, UseMultipath)
+	} else {
+		h = append(h,
+			NewIface(RateInit, RateSchedule, UseAQM),
+			Delay(FlowDelay),
+		)
+	}
+	if TunnelDecap != nil {
+		h = append(h, TunnelDecap)
+	}
+	if UseReorderer != nil {
+		h = append(h, UseReorderer)
+	}
+	if DownstreamRewriter != nil {
+		h = append(h, DownstreamRewriter)
+	}
+	h = append(h, NewReceiver())
+	if ACKRewriter != nil {
+		h = append(h, ACKRewriter)
 	}
 	s := NewSim(h)
-	if err := s.Run(); err != nil {
+	if err = s.Run(); err != nil {
 		log.Fatal(err)
 	}
 	if ProfileMemory {
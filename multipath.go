@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Path is a chain of Handlers that make up one path through the network, for
+// example an Iface and a Delay.
+type Path []Handler
+
+// Multipath is a Handler that sends each flow's data packets over one of
+// several Paths, so the subflows of a multipath connection may cross distinct
+// bottlenecks.  The Handlers in each Path run within the Multipath's node, with
+// their timers multiplexed over it, and the output of the last Handler in a
+// Path is sent on to the next node.  The Path for each flow is given by its
+// index in route, and flows not listed use Path 0.  ACKs are passed through,
+// as the return path has no delay.
+type Multipath struct {
+	path  []Path
+	route []int
+	total []int
+	err   error
+}
+
+// pathDing is used as timer data for the Handlers in a Path.
+type pathDing struct {
+	path  int
+	stage int
+	data  any
+}
+
+// NewMultipath returns a new Multipath, with the given routes (Path indexes by
+// flow), and Paths.
+func NewMultipath(route []int, path ...Path) *Multipath {
+	return &Multipath{
+		path,                   // path
+		route,                  // route
+		make([]int, len(path)), // total
+		nil,                    // err
+	}
+}
+
+// pathOf returns the Path index for the given flow.
+func (m *Multipath) pathOf(flow FlowID) int {
+	if int(flow) < len(m.route) {
+		return m.route[flow]
+	}
+	return 0
+}
+
+// Start implements Starter.
+func (m *Multipath) Start(node Node) (err error) {
+	for p, h := range m.path {
+		if len(h) == 0 {
+			return fmt.Errorf("multipath: path %d has no handlers", p)
+		}
+		for s := range h {
+			if t, ok := h[s].(Starter); ok {
+				if err = t.Start(m.node(node, p, s)); err != nil {
+					return
+				}
+			}
+		}
+	}
+	for f, p := range m.route {
+		if p < 0 || p >= len(m.path) {
+			return fmt.Errorf("multipath: flow %d routed to invalid path %d",
+				f, p)
+		}
+	}
+	return
+}
+
+// Handle implements Handler.
+func (m *Multipath) Handle(pkt Packet, node Node) error {
+	if pkt.ACK {
+		node.Send(pkt)
+		return nil
+	}
+	p := m.pathOf(pkt.Flow)
+	m.total[p]++
+	if err := m.path[p][0].Handle(pkt, m.node(node, p, 0)); err != nil {
+		return err
+	}
+	return m.err
+}
+
+// Ding implements Dinger.
+func (m *Multipath) Ding(data any, node Node) error {
+	d := data.(pathDing)
+	h := m.path[d.path][d.stage]
+	r, ok := h.(Dinger)
+	if !ok {
+		return fmt.Errorf("multipath: %T called Timer so must implement Dinger",
+			h)
+	}
+	if err := r.Ding(d.data, m.node(node, d.path, d.stage)); err != nil {
+		return err
+	}
+	return m.err
+}
+
+// Stop implements Stopper.
+func (m *Multipath) Stop(node Node) (err error) {
+	for p, h := range m.path {
+		node.Logf("multipath: path:%d packets:%d", p, m.total[p])
+		for s := range h {
+			if t, ok := h[s].(Stopper); ok {
+				if err = t.Stop(m.node(node, p, s)); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// node returns the Node for the given Path and stage.
+func (m *Multipath) node(node Node, path, stage int) pathNode {
+	return pathNode{node, m, path, stage}
+}
+
+// pathNode is the Node given to the Handlers in a Path.  Timers are tagged
+// with the Path and stage, and sent packets go to the next Handler in the
+// Path, or to the next node after the last.
+type pathNode struct {
+	Node
+	multipath *Multipath
+	path      int
+	stage     int
+}
+
+// Timer implements Node.
+func (n pathNode) Timer(delay Clock, data any) {
+	n.Node.Timer(delay, pathDing{n.path, n.stage, data})
+}
+
+// Send implements Node.
+func (n pathNode) Send(pkt Packet) {
+	m := n.multipath
+	s := n.stage + 1
+	if s >= len(m.path[n.path]) {
+		n.Node.Send(pkt)
+		return
+	}
+	err := m.path[n.path][s].Handle(pkt, m.node(n.Node, n.path, s))
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+// Logf implements Node.
+func (n pathNode) Logf(format string, a ...any) {
+	n.Node.Logf(fmt.Sprintf("path:%d ", n.path)+format, a...)
+}
+
+// plotName returns the name of a plot file for the given node, with the Path
+// index inserted before the extension for Paths after the first, so each Path
+// writes its own plots.
+func plotName(node Node, name string) string {
+	if a, ok := node.(aqmNode); ok {
+		node = a.Node
+	}
+	p, ok := node.(pathNode)
+	if !ok || p.path == 0 {
+		return name
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return fmt.Sprintf("%s.path%d%s", name[:i], p.path, name[i:])
+	}
+	return fmt.Sprintf("%s.path%d", name, p.path)
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/rand"
+	"time"
+)
+
+// PI2 implements the single queue PI2 AQM, in which the output p' of a PI
+// controller is squared to get the drop or mark probability for Classic
+// traffic, and multiplied by the coupling factor to get the mark probability
+// for scalable (L4S) traffic.  The queue delay is taken as the sojourn time of
+// the packet at the head of the queue, and marks are applied on dequeue.
+type PI2 struct {
+	queue []Packet
+	pi    pi2
+	rand  *rand.Rand
+	// Plots
+	*aqmPlot
+}
+
+// NewPI2 returns a new PI2.
+func NewPI2(target, tUpdate Clock) *PI2 {
+	p := newAqmPlot()
+	return &PI2{
+		make([]Packet, 0),                 // queue
+		newPI2(target, tUpdate, p),        // pi
+		rand.New(rand.NewSource(AQMSeed)), // rand
+		p,                                 // aqmPlot
+	}
+}
+
+// Start implements Starter.
+func (p *PI2) Start(node Node) error {
+	return p.aqmPlot.Start(node)
+}
+
+// Enqueue implements AQM.
+func (p *PI2) Enqueue(pkt Packet, node Node) {
+	p.pi.update(p.qdelay, node)
+	pkt.Enqueue = node.Now()
+	p.queue = append(p.queue, pkt)
+	p.plotLength(len(p.queue), node.Now())
+}
+
+// Dequeue implements AQM.
+func (p *PI2) Dequeue(node Node) (pkt Packet, ok bool) {
+	p.pi.update(p.qdelay, node)
+	if len(p.queue) == 0 {
+		return
+	}
+	// pop from head
+	pkt, p.queue = p.queue[0], p.queue[1:]
+	ok = true
+
+	// NOTE sender drop logic doesn't work yet, so we do a blind CE instead
+	var m mark
+	switch {
+	case pkt.ECN == ECT1 || pkt.ECN == CE:
+		if p.rand.Float64() < p.pi.scalableProb() {
+			m = markCE
+		}
+	case p.rand.Float64() < p.pi.classicProb():
+		if !pkt.ECN.ECT() {
+			m = markDrop
+		} else {
+			m = markCE
+		}
+	}
+	if m != markNone {
+		pkt.ECN = CE
+	}
+
+	p.plotSojourn(node.Now()-pkt.Enqueue, len(p.queue) == 0, node.Now())
+	p.plotLength(len(p.queue), node.Now())
+	p.plotMark(m, node.Now())
+
+	return
+}
+
+// qdelay returns the queue delay at the given time.
+func (p *PI2) qdelay(now Clock) Clock {
+	if len(p.queue) == 0 {
+		return 0
+	}
+	return now - p.queue[0].Enqueue
+}
+
+// Stop implements Stopper.
+func (p *PI2) Stop(node Node) error {
+	return p.aqmPlot.Stop(node)
+}
+
+// Peek implements AQM.
+func (p *PI2) Peek(node Node) (pkt Packet, ok bool) {
+	if len(p.queue) == 0 {
+		return
+	}
+	ok = true
+	pkt = p.queue[0]
+	return
+}
+
+// Len implements AQM.
+func (p *PI2) Len() int {
+	return len(p.queue)
+}
+
+// pi2 is the core PI controller for PI2 and DualPI2, according to RFC 9332
+// Appendix A.  The base probability p' is updated at each update interval.
+// Since AQMs don't have their own timers, updates are done on enqueue and
+// dequeue for each interval that has elapsed.
+type pi2 struct {
+	// parameters
+	target  Clock
+	tUpdate Clock
+	// variables
+	prob       float64 // p'
+	qdelayOld  Clock
+	updateNext Clock
+	// for plotting
+	*aqmPlot
+}
+
+// newPI2 returns a new pi2.
+func newPI2(target, tUpdate Clock, plot *aqmPlot) pi2 {
+	return pi2{
+		target,  // target
+		tUpdate, // tUpdate
+		0,       // prob
+		0,       // qdelayOld
+		tUpdate, // updateNext
+		plot,    // aqmPlot
+	}
+}
+
+// update updates p' for each update interval that has elapsed, using the given
+// function to get the queue delay at the time of each update.
+func (p *pi2) update(qdelay func(Clock) Clock, node Node) {
+	for node.Now() >= p.updateNext {
+		q := qdelay(p.updateNext)
+		e := q - p.target
+		d := q - p.qdelayOld
+		p.prob += Pi2Alpha*time.Duration(e).Seconds() +
+			Pi2Beta*time.Duration(d).Seconds()
+		p.prob = min(max(p.prob, 0), 1)
+		p.qdelayOld = q
+		if p.aqmPlot != nil {
+			p.plotDeltaSigma(d, e, p.updateNext)
+		}
+		p.updateNext += p.tUpdate
+	}
+}
+
+// classicProb returns the drop or mark probability for Classic traffic, p'^2,
+// limited to the maximum Classic probability.
+func (p *pi2) classicProb() float64 {
+	return min(p.prob*p.prob, 1.0/(Pi2K*Pi2K))
+}
+
+// scalableProb returns the coupled mark probability for scalable traffic.
+func (p *pi2) scalableProb() float64 {
+	return min(p.prob*Pi2K, 1)
+}
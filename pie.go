@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/rand"
+	"time"
+)
+
+// PIE implements the Proportional Integral controller Enhanced AQM (RFC 8033).
+// The queue delay is taken as the sojourn time of the most recently dequeued
+// packet, or 0 if the queue is empty, as in the Linux implementation by
+// default.  Marks are applied on enqueue, and the drop probability is updated
+// on enqueue and dequeue for each update interval that has elapsed.
+type PIE struct {
+	queue  []Packet
+	length Bytes
+	maxLen Bytes // largest packet length seen
+	// parameters
+	target  Clock
+	tUpdate Clock
+	// variables
+	prob           float64
+	qdelay         Clock
+	qdelayOld      Clock
+	burstAllowance Clock
+	updateNext     Clock
+	rand           *rand.Rand
+	// Plots
+	*aqmPlot
+}
+
+// NewPIE returns a new PIE.
+func NewPIE(target, tUpdate Clock) *PIE {
+	return &PIE{
+		make([]Packet, 0),                 // queue
+		0,                                 // length
+		0,                                 // maxLen
+		target,                            // target
+		tUpdate,                           // tUpdate
+		0,                                 // prob
+		0,                                 // qdelay
+		0,                                 // qdelayOld
+		PieMaxBurst,                       // burstAllowance
+		tUpdate,                           // updateNext
+		rand.New(rand.NewSource(AQMSeed)), // rand
+		newAqmPlot(),                      // aqmPlot
+	}
+}
+
+// Start implements Starter.
+func (p *PIE) Start(node Node) error {
+	return p.aqmPlot.Start(node)
+}
+
+// Enqueue implements AQM.
+func (p *PIE) Enqueue(pkt Packet, node Node) {
+	p.update(node)
+	var m mark
+	if p.markEarly() {
+		// NOTE sender drop logic doesn't work yet, so we do a blind CE instead
+		if pkt.ECN.ECT() && p.prob <= PieMarkECNThreshold {
+			m = markCE
+		} else {
+			m = markDrop
+		}
+		pkt.ECN = CE
+	}
+	pkt.Enqueue = node.Now()
+	p.queue = append(p.queue, pkt)
+	p.length += pkt.Len
+	p.maxLen = max(p.maxLen, pkt.Len)
+	p.plotLength(len(p.queue), node.Now())
+	p.plotMark(m, node.Now())
+}
+
+// markEarly returns true if the next enqueued packet should be marked.  As in
+// RFC 8033, packets aren't marked with less than two packets in the queue,
+// taken as two of the largest packet length seen.
+func (p *PIE) markEarly() bool {
+	if p.burstAllowance > 0 {
+		return false
+	}
+	if p.qdelayOld < p.target/2 && p.prob < 0.2 {
+		return false
+	}
+	if p.length <= 2*p.maxLen {
+		return false
+	}
+	return p.rand.Float64() < p.prob
+}
+
+// Dequeue implements AQM.
+func (p *PIE) Dequeue(node Node) (pkt Packet, ok bool) {
+	p.update(node)
+	if len(p.queue) == 0 {
+		return
+	}
+	// pop from head
+	pkt, p.queue = p.queue[0], p.queue[1:]
+	p.length -= pkt.Len
+	ok = true
+
+	s := node.Now() - pkt.Enqueue
+	if len(p.queue) > 0 {
+		p.qdelay = s
+	} else {
+		p.qdelay = 0
+	}
+
+	p.plotSojourn(s, len(p.queue) == 0, node.Now())
+	p.plotLength(len(p.queue), node.Now())
+
+	return
+}
+
+// update calculates the drop probability for each update interval that has
+// elapsed.
+func (p *PIE) update(node Node) {
+	for node.Now() >= p.updateNext {
+		p.calculate(p.updateNext)
+		p.updateNext += p.tUpdate
+	}
+}
+
+// calculate updates the drop probability according to RFC 8033 Section 4.2,
+// and the burst allowance according to Section 4.4.
+func (p *PIE) calculate(now Clock) {
+	e := p.qdelay - p.target
+	d := p.qdelay - p.qdelayOld
+	a := PieAlpha*time.Duration(e).Seconds() + PieBeta*time.Duration(d).Seconds()
+	switch {
+	case p.prob < 0.000001:
+		a /= 2048
+	case p.prob < 0.00001:
+		a /= 512
+	case p.prob < 0.0001:
+		a /= 128
+	case p.prob < 0.001:
+		a /= 32
+	case p.prob < 0.01:
+		a /= 8
+	case p.prob < 0.1:
+		a /= 2
+	}
+	if p.prob >= 0.1 && a > 0.02 {
+		a = 0.02
+	}
+	p.prob += a
+	if p.qdelay == 0 && p.qdelayOld == 0 {
+		p.prob *= 0.98
+	}
+	if p.qdelay > Clock(250*time.Millisecond) {
+		p.prob += 0.02
+	}
+	p.prob = min(max(p.prob, 0), 1)
+	if p.burstAllowance > 0 {
+		p.burstAllowance = max(p.burstAllowance-p.tUpdate, 0)
+	} else if p.prob == 0 && p.qdelay < p.target/2 &&
+		p.qdelayOld < p.target/2 {
+		p.burstAllowance = PieMaxBurst
+	}
+	p.qdelayOld = p.qdelay
+	p.plotDeltaSigma(d, e, now)
+}
+
+// Stop implements Stopper.
+func (p *PIE) Stop(node Node) error {
+	return p.aqmPlot.Stop(node)
+}
+
+// Peek implements AQM.
+func (p *PIE) Peek(node Node) (pkt Packet, ok bool) {
+	if len(p.queue) == 0 {
+		return
+	}
+	ok = true
+	pkt = p.queue[0]
+	return
+}
+
+// Len implements AQM.
+func (p *PIE) Len() int {
+	return len(p.queue)
+}
@@ -1,7 +1,8 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+// Package plot writes plots for scim in the xplot format.
+package plot
 
 import (
 	"bufio"
@@ -9,8 +10,15 @@ import (
 	"os"
 	"strconv"
 	"text/template"
+	"time"
+
+	"github.com/heistp/scim/sim"
 )
 
+// Duration is the length of the X axis for plots that start at 0, which is
+// normally the test duration.
+var Duration time.Duration
+
 // xplotHeader is a Go template to generate the .xpl file header.
 const xplotHeader = `double double
 title
@@ -37,22 +45,24 @@ invisible {{.Duration}} {{if .Y.Max}}{{.Y.Max}}{{else}}0{{end}}
 {{end -}}
 `
 
+// Axis contains the settings for a plot axis.
 type Axis struct {
 	Label string
 	Units string
 	Max   string
 }
 
+// Xplot is a plot in the xplot format.
 type Xplot struct {
 	Title       string
 	X           Axis
 	Y           Axis
 	NonzeroAxis bool
-	Decimation  Clock
+	Decimation  sim.Clock
 	Duration    string
 	file        *os.File
 	writer      *bufio.Writer
-	prior       map[int]Clock
+	prior       map[int]sim.Clock
 }
 
 type symbology int
@@ -63,20 +73,22 @@ const (
 	symbologyX
 )
 
-type color int
+// Color is a color for plotted points and lines.
+type Color int
 
 const (
-	colorWhite color = iota
-	colorGreen
-	colorRed
-	colorBlue
-	colorYellow
-	colorPurple
-	colorOrange
-	colorMagenta
-	colorPink
+	White Color = iota
+	Green
+	Red
+	Blue
+	Yellow
+	Purple
+	Orange
+	Magenta
+	Pink
 )
 
+// Open creates the plot file with the given name, and writes the header.
 func (p *Xplot) Open(name string) (err error) {
 	var t *template.Template
 	if t, err = template.New("XplotHeader").Parse(xplotHeader); err != nil {
@@ -87,40 +99,56 @@ func (p *Xplot) Open(name string) (err error) {
 	}
 	p.Duration = strconv.FormatFloat(Duration.Seconds(), 'f', -1, 64)
 	p.writer = bufio.NewWriter(p.file)
-	p.prior = make(map[int]Clock)
+	p.prior = make(map[int]sim.Clock)
 	err = t.Execute(p.writer, p)
 	return
 }
 
-func (p *Xplot) Dot(now Clock, y any, color color) {
+// Dot plots a dot.  Points are discarded if the plot has not been opened.
+func (p *Xplot) Dot(now sim.Clock, y any, color Color) {
+	if p.writer == nil {
+		return
+	}
 	if !p.decimate(now, symbologyDot, color) {
 		fmt.Fprintf(p.writer, "dot %s %s %d\n", now, y, color)
 	}
 }
 
-func (p *Xplot) Plus(now Clock, y any, color color) {
+// Plus plots a plus.  Points are discarded if the plot has not been opened.
+func (p *Xplot) Plus(now sim.Clock, y any, color Color) {
+	if p.writer == nil {
+		return
+	}
 	if !p.decimate(now, symbologyPlus, color) {
 		fmt.Fprintf(p.writer, "+ %s %s %d\n", now, y, color)
 	}
 }
 
-func (p *Xplot) PlotX(now Clock, y any, color color) {
+// PlotX plots an x.  Points are discarded if the plot has not been opened.
+func (p *Xplot) PlotX(now sim.Clock, y any, color Color) {
+	if p.writer == nil {
+		return
+	}
 	if !p.decimate(now, symbologyX, color) {
 		fmt.Fprintf(p.writer, "x %s %s %d\n", now, y, color)
 	}
 }
 
-type pointFunc func(Clock, any, color)
+type pointFunc func(sim.Clock, any, Color)
 
-func (p *Xplot) Line(x0, y0, x1, y1 any, color color) {
+// Line plots a line.  Lines are discarded if the plot has not been opened.
+func (p *Xplot) Line(x0, y0, x1, y1 any, color Color) {
+	if p.writer == nil {
+		return
+	}
 	fmt.Fprintf(p.writer, "line %s %s %s %s %d\n", x0, y0, x1, y1, color)
 }
 
 // decimate returns true if the given symbology and color may be plotted now.
-func (p *Xplot) decimate(now Clock, sym symbology, color color) bool {
+func (p *Xplot) decimate(now sim.Clock, sym symbology, color Color) bool {
 	i := (1024 * (int(sym) + 1)) * (int(color) + 1)
 	var ok bool
-	var c Clock
+	var c sim.Clock
 	if c, ok = p.prior[i]; !ok || now-c >= p.Decimation {
 		p.prior[i] = now
 		return false
@@ -128,6 +156,7 @@ func (p *Xplot) decimate(now Clock, sym symbology, color color) bool {
 	return true
 }
 
+// Close writes the trailer, and closes the plot file.
 func (p *Xplot) Close() error {
 	fmt.Fprintf(p.writer, "go\n")
 	p.writer.Flush()
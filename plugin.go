@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"time"
+
+	"github.com/heistp/scim/cc"
+)
+
+// flowView implements cc.Flow for a Flow.
+type flowView struct {
+	flow *Flow
+	node Node
+}
+
+// ID implements cc.FlowState.
+func (v flowView) ID() int {
+	return int(v.flow.id)
+}
+
+// Now implements cc.FlowState.
+func (v flowView) Now() time.Duration {
+	return time.Duration(v.node.Now())
+}
+
+// MSS implements cc.FlowState.
+func (v flowView) MSS() int64 {
+	return int64(v.flow.mss())
+}
+
+// Cwnd implements cc.FlowState.
+func (v flowView) Cwnd() int64 {
+	return int64(v.flow.cwnd)
+}
+
+// InFlight implements cc.FlowState.
+func (v flowView) InFlight() int64 {
+	return int64(v.flow.inFlight)
+}
+
+// RTT implements cc.FlowState.
+func (v flowView) RTT() time.Duration {
+	return time.Duration(v.flow.rtt)
+}
+
+// SRTT implements cc.FlowState.
+func (v flowView) SRTT() time.Duration {
+	return time.Duration(v.flow.srtt)
+}
+
+// MinRTT implements cc.FlowState.
+func (v flowView) MinRTT() time.Duration {
+	return time.Duration(v.flow.minRtt)
+}
+
+// MaxRTT implements cc.FlowState.
+func (v flowView) MaxRTT() time.Duration {
+	return time.Duration(v.flow.maxRtt)
+}
+
+// PacingRate implements cc.FlowState.
+func (v flowView) PacingRate() int64 {
+	return int64(v.flow.getPacingRate())
+}
+
+// Seq implements cc.FlowState.
+func (v flowView) Seq() int64 {
+	return int64(v.flow.seq)
+}
+
+// ACKed implements cc.FlowState.
+func (v flowView) ACKed() int64 {
+	return int64(v.flow.receiveNext)
+}
+
+// SignalNext implements cc.FlowState.
+func (v flowView) SignalNext() int64 {
+	return int64(v.flow.signalNext)
+}
+
+// Alpha implements cc.FlowState.
+func (v flowView) Alpha() float64 {
+	return v.flow.alpha
+}
+
+// RateSample implements cc.FlowState.
+func (v flowView) RateSample() cc.RateSample {
+	return exportRateSample(v.flow.rs)
+}
+
+// SetCwnd implements cc.Flow.
+func (v flowView) SetCwnd(cwnd int64) {
+	v.flow.setCWND(Bytes(cwnd), v.node)
+}
+
+// SetPacingRate implements cc.Flow.
+func (v flowView) SetPacingRate(rate int64) {
+	v.flow.pacingRate = Bitrate(rate)
+}
+
+// EndSignalWindow implements cc.Flow.
+func (v flowView) EndSignalWindow() {
+	v.flow.signalNext = v.flow.seq
+}
+
+// Logf implements cc.Flow.
+func (v flowView) Logf(format string, a ...any) {
+	v.node.Logf(format, a...)
+}
+
+// exportRateSample returns the cc.RateSample for a rateSample.
+func exportRateSample(rs rateSample) cc.RateSample {
+	return cc.RateSample{
+		DeliveryRate: int64(rs.deliveryRate),
+		Delivered:    int64(rs.delivered),
+		DeliveredCE:  int64(rs.deliveredCE),
+		Interval:     time.Duration(rs.interval),
+		RTT:          time.Duration(rs.rtt),
+		AppLimited:   rs.appLimited,
+	}
+}
+
+// PluginCCA is a CCA that wraps a cc.CCA from an external package.  Grow is
+// not called for ACKs with ECE or ESCE set.
+type PluginCCA struct {
+	cca cc.CCA
+}
+
+// NewPluginCCA returns a new PluginCCA.
+func NewPluginCCA(cca cc.CCA) *PluginCCA {
+	return &PluginCCA{
+		cca, // cca
+	}
+}
+
+// grow implements CCA.
+func (p *PluginCCA) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE || pkt.ESCE {
+		return
+	}
+	p.cca.Grow(int64(acked), flowView{flow, node})
+}
+
+// handleCE implements handleCEer.
+func (p *PluginCCA) handleCE(flow *Flow, node Node) {
+	if h, ok := p.cca.(cc.CEHandler); ok {
+		h.HandleCE(flowView{flow, node})
+	}
+}
+
+// handleSCE implements handleSCEer.
+func (p *PluginCCA) handleSCE(flow *Flow, node Node) {
+	if h, ok := p.cca.(cc.SCEHandler); ok {
+		h.HandleSCE(flowView{flow, node})
+	}
+}
+
+// updateRtt implements updateRtter.
+func (p *PluginCCA) updateRtt(rtt Clock, flow *Flow, node Node) {
+	if u, ok := p.cca.(cc.RTTUpdater); ok {
+		u.UpdateRTT(time.Duration(rtt), flowView{flow, node})
+	}
+}
+
+// handleRateSample implements handleRateSampler.
+func (p *PluginCCA) handleRateSample(rs rateSample, flow *Flow, node Node) {
+	if h, ok := p.cca.(cc.RateSampleHandler); ok {
+		h.HandleRateSample(exportRateSample(rs), flowView{flow, node})
+	}
+}
+
+// slowStartExit implements slowStartExiter.
+func (p *PluginCCA) slowStartExit(flow *Flow, node Node) {
+	if x, ok := p.cca.(cc.SlowStartExiter); ok {
+		x.SlowStartExit(flowView{flow, node})
+	}
+}
+
+// PluginSlowStart is a SlowStart that wraps a cc.SlowStart from an external
+// package.  As for the built-in SlowStarts, Grow is called for every ACK,
+// including those with ECE or ESCE set.
+type PluginSlowStart struct {
+	ss cc.SlowStart
+}
+
+// NewPluginSlowStart returns a new PluginSlowStart.
+func NewPluginSlowStart(ss cc.SlowStart) *PluginSlowStart {
+	return &PluginSlowStart{
+		ss, // ss
+	}
+}
+
+// grow implements SlowStart.
+func (p *PluginSlowStart) grow(acked Bytes, flow *Flow, node Node) (
+	exit bool) {
+	return p.ss.Grow(int64(acked), flowView{flow, node})
+}
+
+// init implements initer.
+func (p *PluginSlowStart) init(flow *Flow, node Node) {
+	if i, ok := p.ss.(cc.Initer); ok {
+		i.Init(flowView{flow, node})
+	}
+}
+
+// handleCE implements handleCESSer.
+func (p *PluginSlowStart) handleCE(flow *Flow, node Node) (exit bool) {
+	if h, ok := p.ss.(cc.SlowStartCEHandler); ok {
+		exit = h.HandleCE(flowView{flow, node})
+	}
+	return
+}
+
+// handleSCE implements handleSCESSer.
+func (p *PluginSlowStart) handleSCE(flow *Flow, node Node) (exit bool) {
+	if h, ok := p.ss.(cc.SlowStartSCEHandler); ok {
+		exit = h.HandleSCE(flowView{flow, node})
+	}
+	return
+}
+
+// updateRtt implements updateRtter.
+func (p *PluginSlowStart) updateRtt(rtt Clock, flow *Flow, node Node) {
+	if u, ok := p.ss.(cc.RTTUpdater); ok {
+		u.UpdateRTT(time.Duration(rtt), flowView{flow, node})
+	}
+}
+
+// handleRateSample implements handleRateSampleSSer.
+func (p *PluginSlowStart) handleRateSample(rs rateSample, flow *Flow,
+	node Node) (exit bool) {
+	if h, ok := p.ss.(cc.SlowStartRateSampleHandler); ok {
+		exit = h.HandleRateSample(exportRateSample(rs), flowView{flow, node})
+	}
+	return
+}
+
+// PluginResponder is a Responder that wraps a cc.Responder from an external
+// package.
+type PluginResponder struct {
+	responder cc.Responder
+}
+
+// NewPluginResponder returns a new PluginResponder.
+func NewPluginResponder(responder cc.Responder) PluginResponder {
+	return PluginResponder{
+		responder, // responder
+	}
+}
+
+// Respond implements Responder.  The flow is passed as a cc.FlowState that may
+// not be converted to a cc.Flow, as Responders only return the new cwnd.
+func (p PluginResponder) Respond(flow *Flow, node Node) (cwnd Bytes) {
+	v := struct{ cc.FlowState }{flowView{flow, node}}
+	return Bytes(p.responder.Respond(v))
+}
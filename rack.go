@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+// reorderDetector detects reordering for a Flow, by counting the losses that
+// would be detected by a classic dup ACK threshold, and by RACK-style time-based
+// loss detection (RFC 8985).  Since there is no SACK, the send time echoed in
+// each ACK stands in for the most recently delivered segment.  Losses are
+// checked on each ACK, not with a reordering timer.  As scim doesn't handle
+// loss, every detected loss is spurious, which is counted when the segment is
+// cumulatively ACKed, and widens the reordering window, as on DSACK.
+type reorderDetector struct {
+	sent            []sentSegment // segments in flight, in send order
+	xmit            Clock         // RACK.xmit_ts
+	rtt             Clock         // RACK.rtt
+	reoWndMult      int           // RACK.reo_wnd_mult
+	dupACKs         int           // consecutive dup ACKs
+	dupACKTotal     int
+	dupThreshLosses int
+	rackLosses      int
+	spurious        int
+}
+
+// sentSegment records a sent segment for RACK.
+type sentSegment struct {
+	end  Seq
+	sent Clock
+	lost bool
+}
+
+// newReorderDetector returns a new reorderDetector.
+func newReorderDetector() reorderDetector {
+	return reorderDetector{
+		nil, // sent
+		0,   // xmit
+		0,   // rtt
+		1,   // reoWndMult
+		0,   // dupACKs
+		0,   // dupACKTotal
+		0,   // dupThreshLosses
+		0,   // rackLosses
+		0,   // spurious
+	}
+}
+
+// onSend records a sent Packet.
+func (d *reorderDetector) onSend(pkt Packet) {
+	d.sent = append(d.sent, sentSegment{pkt.NextSeq(), pkt.Sent, false})
+}
+
+// onACK updates the detector from an ACK that acked the given bytes.
+func (d *reorderDetector) onACK(pkt Packet, acked Bytes, flow *Flow,
+	now Clock) {
+	if acked == 0 && len(d.sent) > 0 {
+		d.dupACKs++
+		d.dupACKTotal++
+		if d.dupACKs == DupThresh {
+			d.dupThreshLosses++
+		}
+	} else {
+		d.dupACKs = 0
+	}
+	if pkt.Sent >= d.xmit {
+		d.xmit = pkt.Sent
+		d.rtt = now - pkt.Sent
+	}
+	for len(d.sent) > 0 && d.sent[0].end <= pkt.ACKNum {
+		if d.sent[0].lost {
+			d.spurious++
+			d.reoWndMult++
+		}
+		d.sent = d.sent[1:]
+	}
+	w := d.reoWnd(flow)
+	for i := range d.sent {
+		s := &d.sent[i]
+		if s.sent >= d.xmit {
+			break
+		}
+		if !s.lost && now-s.sent >= d.rtt+w {
+			s.lost = true
+			d.rackLosses++
+		}
+	}
+}
+
+// reoWnd returns the RACK reordering window.
+func (d *reorderDetector) reoWnd(flow *Flow) Clock {
+	if flow.minRtt == ClockMax {
+		return 0
+	}
+	w := Clock(d.reoWndMult) * flow.minRtt / RACKReoWndDivisor
+	return min(w, flow.srtt)
+}
+
+// log logs the reordering stats for the flow, if it saw any dup ACKs.
+func (d *reorderDetector) log(flow *Flow, node Node) {
+	if d.dupACKTotal == 0 {
+		return
+	}
+	node.Logf("flow:%d reordering dup-acks:%d dupthresh-losses:%d "+
+		"rack-losses:%d spurious:%d reo-wnd:%sms", flow.id, d.dupACKTotal,
+		d.dupThreshLosses, d.rackLosses, d.spurious,
+		d.reoWnd(flow).StringMS())
+}
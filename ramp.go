@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import "math/rand"
 
@@ -42,13 +42,13 @@ func (r *Ramp) Dequeue(node Node) (pkt Packet) {
 		}
 	}
 	if m {
-		if pkt.SCECapable {
-			pkt.SCE = true
+		if pkt.SCECapable && pkt.ECN == ECT0 {
+			pkt.ECN = ECT1
 		}
 		r.sceAcc++
 		if r.sceAcc == Tau {
 			if !pkt.SCECapable {
-				pkt.CE = true
+				pkt.ECN = CE
 			}
 			r.sceAcc = 0
 		}
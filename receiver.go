@@ -1,9 +1,10 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
+	"container/heap"
 	"strconv"
 	"time"
 )
@@ -18,38 +19,103 @@ type Receiver struct {
 	ackedPackets    int
 	sceMarks        int
 	ceMarks         int
+	thinnedACKs     int
+	groCoalesced    int
 	total           []Bytes
 	maxRTTFlow      FlowID
 	thruput         Xplot
 	flow            []rflow
 }
 
+// ACKPolicy selects how the Receiver ACKs a flow.  With delayed ACKs, every
+// Every segments are ACKed, and the first QuickACKs segments are ACKed
+// immediately, as in Linux's quickack mode.  With GROWindow, contiguous packets
+// with the same ECN codepoint arriving within the window after the first are
+// coalesced, up to GROMaxBytes, and ACKed as one.  With Thin, only 1 in Thin
+// ACKs is sent, and the newest of the others is held, then sent if no ACK
+// follows within ACKThinFlushTime.
+type ACKPolicy struct {
+	DelayedACKTime Clock // max ACK delay, or 0 to ACK every segment
+	QuickACKSignal bool  // if true, ACK SCE or CE immediately (see config)
+	Every          int   // segments per ACK, with delayed ACKs
+	QuickACKs      int   // segments ACKed immediately at the start
+	Thin           int   // send 1 in Thin ACKs, if > 1
+	GROWindow      Clock // GRO coalescing window, or 0 for none
+}
+
+// flowACKPolicy returns the ACKPolicy for the given flow from FlowACKPolicy,
+// or DefaultACKPolicy if it's not listed.
+func flowACKPolicy(id FlowID) ACKPolicy {
+	if int(id) < len(FlowACKPolicy) {
+		return FlowACKPolicy[id]
+	}
+	return DefaultACKPolicy
+}
+
 // rflow stores receiver information about a single flow.
 type rflow struct {
+	policy     ACKPolicy
 	buf        pktbuf
-	delayAck   bool
-	next       Seq // rcv.nxt
+	unacked    int    // segments received since the last ACK
+	last       Packet // last packet received
+	quickAcks  int    // quick ACKs remaining
+	next       Seq    // rcv.nxt
 	priorAcked Seq
 	priorECE   bool
 	priorESCE  bool
 	tel        []Telemetry
+	cePkts     int
+	ceBytes    Bytes
+	scePkts    int
+	sceBytes   Bytes
+	gro        []Packet // packets being coalesced
+	groBytes   Bytes
+	groGen     int    // GRO batch generation, to ignore stale flush timers
+	thinCount  int    // ACKs since the last one sent, when thinning
+	held       Packet // newest ACK held back by thinning
+	holding    bool   // true if an ACK is held
+	thinGen    int    // held ACK generation, to ignore stale flush timers
+}
+
+// groFlush is used as timer data to flush a GRO batch.
+type groFlush struct {
+	Flow FlowID
+	gen  int
 }
 
-// sendAck sends an ack for the given Packet.
-func (f *rflow) sendAck(pkt Packet, node Node) {
+// ackThinFlush is used as timer data to send an ACK held by thinning.
+type ackThinFlush struct {
+	Flow FlowID
+	gen  int
+}
+
+// ackFor returns an ACK for the given Packet, and updates the ACK state.
+func (f *rflow) ackFor(pkt Packet) Packet {
 	pkt.ACK = true
 	pkt.ACKNum = f.next
-	if pkt.CE {
+	if pkt.IsCE() {
 		pkt.ECE = true
-		pkt.CE = false
 	}
-	if pkt.SCE {
+	if pkt.IsSCE() {
 		pkt.ESCE = true
-		pkt.SCE = false
 	}
+	pkt.ECN = NotECT
 	f.priorECE = pkt.ECE
 	f.priorESCE = pkt.ESCE
 	f.priorAcked = pkt.Seq
+	if AccurateECN {
+		pkt.ECE = false
+		pkt.AccECN = true
+		pkt.CEPkts = f.cePkts
+		pkt.CEBytes = f.ceBytes
+	}
+	if AccurateSCE {
+		pkt.ESCE = false
+		f.priorESCE = false
+		pkt.AccSCE = true
+		pkt.SCEPkts = f.scePkts
+		pkt.SCEBytes = f.sceBytes
+	}
 	if len(f.tel) > 0 {
 		var tt Telemetry
 		for _, t := range f.tel {
@@ -58,21 +124,37 @@ func (f *rflow) sendAck(pkt Packet, node Node) {
 		pkt.Telemetry = tt
 		f.tel = nil
 	}
-	node.Send(pkt)
+	f.unacked = 0
+	return pkt
 }
 
 // NewReceiver returns a new Receiver.
 func NewReceiver() *Receiver {
-	f := make([]rflow, len(Flows))
-	for range Flows {
+	f := make([]rflow, 0, len(Flows))
+	for i := range Flows {
+		p := flowACKPolicy(FlowID(i))
 		f = append(f, rflow{
-			pktbuf{}, // buf
-			true,     // delayAck
-			0,        // next
-			-1,       // priorAcked
-			false,    // priorECE
-			false,    // priorESCE
-			nil,      // tel
+			p,           // policy
+			pktbuf{},    // buf
+			0,           // unacked
+			Packet{},    // last
+			p.QuickACKs, // quickAcks
+			0,           // next
+			-1,          // priorAcked
+			false,       // priorECE
+			false,       // priorESCE
+			nil,         // tel
+			0,           // cePkts
+			0,           // ceBytes
+			0,           // scePkts
+			0,           // sceBytes
+			nil,         // gro
+			0,           // groBytes
+			0,           // groGen
+			0,           // thinCount
+			Packet{},    // held
+			false,       // holding
+			0,           // thinGen
 		})
 	}
 	return &Receiver{
@@ -84,6 +166,8 @@ func NewReceiver() *Receiver {
 		0,                         // ackedPackets
 		0,                         // sceMarks
 		0,                         // ceMarks
+		0,                         // thinnedACKs
+		0,                         // groCoalesced
 		make([]Bytes, len(Flows)), // total
 		0,                         // maxRTTFlow
 		Xplot{
@@ -135,24 +219,42 @@ func (r *Receiver) receive(pkt Packet, node Node) {
 	if pkt.ACK {
 		panic("receiver: ACK receive not implemented")
 	}
-	if pkt.CE {
+	f := &r.flow[pkt.Flow]
+	if f.policy.GROWindow > 0 && !pkt.SYN {
+		r.coalesce(pkt, node)
+		return
+	}
+	r.acknowledge(pkt, r.accept(pkt), 1, node)
+}
+
+// accept updates the flow's state for an incoming Packet, and returns true if
+// it should be ACKed immediately, because it's out-of-order or fills a hole.
+func (r *Receiver) accept(pkt Packet) (immediate bool) {
+	if pkt.IsCE() {
 		r.ceMarks++
 	}
-	if pkt.SCE {
+	if pkt.IsSCE() {
 		r.sceMarks++
 	}
 	f := &r.flow[pkt.Flow]
-	var a bool
+	if pkt.IsCE() {
+		f.cePkts++
+		f.ceBytes += pkt.SegmentLen()
+	}
+	if pkt.IsSCE() {
+		f.scePkts++
+		f.sceBytes += pkt.SegmentLen()
+	}
 	if pkt.Seq != f.next || len(f.buf) > 0 {
-		a = true
+		immediate = true
 		if pkt.Seq == f.next {
 			f.next = pkt.NextSeq()
-			for len(f.buf) > 0 && f.buf[0].Seq == f.next {
-				p := f.buf.Pop().(Packet)
-				f.next = p.NextSeq()
+			for len(f.buf) > 0 && f.buf[0].Seq <= f.next {
+				p := heap.Pop(&f.buf).(Packet)
+				f.next = max(f.next, p.NextSeq())
 			}
-		} else {
-			f.buf.Push(pkt)
+		} else if pkt.Seq > f.next {
+			heap.Push(&f.buf, pkt)
 		}
 	} else {
 		f.next = pkt.NextSeq()
@@ -160,45 +262,124 @@ func (r *Receiver) receive(pkt Packet, node Node) {
 	if pkt.Telemetry != (Telemetry{}) {
 		f.tel = append(f.tel, pkt.Telemetry)
 	}
-	if a || // immediate ACK due to out-of-order packet or filling of hole
-		DelayedACKTime == 0 || // delayed ACKs disabled
-		(QuickACKSignal && (pkt.CE || pkt.SCE)) || // quick ACK all signals
-		pkt.SCE != f.priorESCE || pkt.CE != f.priorECE { // "Advanced" handling
+	return
+}
+
+// acknowledge ACKs the given Packet, which stands for segs segments, either
+// immediately or delayed, according to the flow's ACKPolicy.
+func (r *Receiver) acknowledge(pkt Packet, immediate bool, segs int,
+	node Node) {
+	f := &r.flow[pkt.Flow]
+	p := f.policy
+	f.last = pkt
+	q := f.quickAcks > 0 && !pkt.SYN
+	if q {
+		f.quickAcks--
+	}
+	// with AccurateSCE, SCE marks are counted, so needn't be ACKed immediately
+	sce := pkt.IsSCE() && !AccurateSCE
+	if immediate || // out-of-order packet or filling of hole
+		pkt.SYN || // handshake
+		q || // quickack mode
+		p.DelayedACKTime == 0 || // delayed ACKs disabled
+		(p.QuickACKSignal && (pkt.IsCE() || sce)) || // quick ACK all signals
+		sce != f.priorESCE || pkt.IsCE() != f.priorECE { // "Advanced" handling
 		r.sendAck(pkt, node)
-		f.delayAck = true
 		return
 	}
-	if !f.delayAck {
+	f.unacked += segs
+	if f.unacked >= p.Every {
 		r.sendAck(pkt, node)
+	} else if f.unacked == segs {
+		node.Timer(p.DelayedACKTime, pkt)
+	}
+}
+
+// coalesce adds a Packet to the flow's GRO batch, first flushing the batch if
+// the Packet can't be coalesced with it.
+func (r *Receiver) coalesce(pkt Packet, node Node) {
+	f := &r.flow[pkt.Flow]
+	if n := len(f.gro); n > 0 {
+		l := f.gro[n-1]
+		if pkt.Seq != l.NextSeq() || pkt.ECN != l.ECN ||
+			f.groBytes+pkt.Len > GROMaxBytes {
+			r.flushGRO(f, node)
+		}
+	}
+	if len(f.gro) == 0 {
+		f.groGen++
+		node.Timer(f.policy.GROWindow, groFlush{pkt.Flow, f.groGen})
 	} else {
-		r.scheduleAck(pkt, node)
+		r.groCoalesced++
 	}
-	f.delayAck = !f.delayAck
+	f.gro = append(f.gro, pkt)
+	f.groBytes += pkt.Len
+}
+
+// flushGRO accepts the packets in the flow's GRO batch, and ACKs them as one.
+func (r *Receiver) flushGRO(f *rflow, node Node) {
+	var i bool
+	for _, p := range f.gro {
+		if r.accept(p) {
+			i = true
+		}
+	}
+	r.acknowledge(f.gro[len(f.gro)-1], i, len(f.gro), node)
+	f.gro = f.gro[:0]
+	f.groBytes = 0
 }
 
 // Ding implements Dinger.
 func (r *Receiver) Ding(data any, node Node) error {
-	p := data.(Packet)
-	f := &r.flow[p.Flow]
-	if f.priorAcked < p.Seq {
-		p.Delayed = true
-		r.sendAck(p, node)
+	switch v := data.(type) {
+	case Packet: // delayed ACK
+		f := &r.flow[v.Flow]
+		if f.priorAcked < v.Seq {
+			p := f.last
+			p.Delayed = true
+			r.sendAck(p, node)
+		}
+	case groFlush:
+		f := &r.flow[v.Flow]
+		if v.gen == f.groGen && len(f.gro) > 0 {
+			r.flushGRO(f, node)
+		}
+	case ackThinFlush:
+		f := &r.flow[v.Flow]
+		if v.gen == f.thinGen && f.holding {
+			f.holding = false
+			f.thinCount = 0
+			node.Send(f.held)
+			r.ackedPackets++
+		}
 	}
 	return nil
 }
 
-// sendAck sends an ack for the given Packet.
+// sendAck sends an ACK for the given Packet, unless it's held back by
+// thinning.  A held ACK is dropped when a newer ACK is sent or held.
 func (r *Receiver) sendAck(pkt Packet, node Node) {
 	f := &r.flow[pkt.Flow]
-	f.sendAck(pkt, node)
+	a := f.ackFor(pkt)
+	if t := f.policy.Thin; t > 1 && !pkt.SYN {
+		if f.holding {
+			r.thinnedACKs++
+		}
+		f.thinCount++
+		if f.thinCount < t {
+			f.held = a
+			f.holding = true
+			f.thinGen++
+			node.Timer(ACKThinFlushTime, ackThinFlush{pkt.Flow, f.thinGen})
+			return
+		}
+		f.thinCount = 0
+		f.holding = false
+	}
+	node.Send(a)
 	r.ackedPackets++
 }
 
-// scheduleAck schedules a delayed acknowledgement.
-func (r *Receiver) scheduleAck(pkt Packet, node Node) {
-	node.Timer(DelayedACKTime, pkt)
-}
-
 func (r *Receiver) updateThoughput(pkt Packet, node Node) {
 	r.count[pkt.Flow] += pkt.Len
 	r.countAll += pkt.Len
@@ -243,7 +424,43 @@ func (r *Receiver) Stop(node Node) error {
 	d := time.Since(r.start)
 	node.Logf("receiver ACK ratio:%f CE:%d SCE:%d",
 		r.ackRatio(), r.ceMarks, r.sceMarks)
+	if r.thinnedACKs > 0 || r.groCoalesced > 0 {
+		node.Logf("receiver thinned ACKs:%d GRO coalesced packets:%d",
+			r.thinnedACKs, r.groCoalesced)
+	}
 	node.Logf("sim performance: %.0f packets/sec",
 		(float64(r.receivedPackets) / d.Seconds()))
 	return nil
 }
+
+// pktbuf is a buffer for packets, using the heap package.
+type pktbuf []Packet
+
+// Len implements heap.Interface.
+func (p pktbuf) Len() int {
+	return len(p)
+}
+
+// Less implements heap.Interface.
+func (p pktbuf) Less(i, j int) bool {
+	return p[i].Seq < p[j].Seq
+}
+
+// Swap implements heap.Interface.
+func (p pktbuf) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// Push implements heap.Interface.
+func (p *pktbuf) Push(x any) {
+	*p = append(*p, x.(Packet))
+}
+
+// Pop implements heap.Interface.
+func (p *pktbuf) Pop() any {
+	o := *p
+	n := len(o)
+	t := o[n-1]
+	*p = o[:n-1]
+	return t
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"testing"
+)
+
+// ackNode is a testNode that records the packets sent.
+type ackNode struct {
+	testNode
+	sent []Packet
+}
+
+func (n *ackNode) Send(pkt Packet) { n.sent = append(n.sent, pkt) }
+
+func TestReceiverReassembly(t *testing.T) {
+	r := NewReceiver()
+	r.flow[0].policy = ACKPolicy{0, false, 1, 0, 1, 0} // ACK every segment
+	n := &ackNode{}
+	seg := func(i int) Packet {
+		return Packet{Flow: 0, Seq: Seq(i) * Seq(MSS), Len: MTU}
+	}
+	// segments 1 and 5 are late, and 3 is duplicated while out-of-order
+	for _, i := range []int{0, 2, 4, 3, 3, 1, 6, 5, 2} {
+		r.receive(seg(i), n)
+	}
+	w := []int{1, 1, 1, 1, 1, 5, 5, 7, 7}
+	if len(n.sent) != len(w) {
+		t.Fatalf("sent %d ACKs, want %d", len(n.sent), len(w))
+	}
+	for i, a := range n.sent {
+		if !a.ACK {
+			t.Fatalf("packet %d sent is not an ACK", i)
+		}
+		if a.ACKNum != seg(w[i]).Seq {
+			t.Fatalf("ACK %d ACKNum:%d, want %d (segment %d)", i, a.ACKNum,
+				seg(w[i]).Seq, w[i])
+		}
+	}
+	if l := len(r.flow[0].buf); l != 0 {
+		t.Fatalf("%d packets left in reassembly buffer, want 0", l)
+	}
+}
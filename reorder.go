@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/rand"
+)
+
+// Reorderer is a Handler that reorders data packets on the forward path.  In
+// swap mode, a packet is held with the given probability until the flow's
+// next packet has passed, or for up to ReorderMaxHold.  In multipath mode,
+// each packet takes a random path, with the given extra delay per path.
+// Reordering may be limited to the given flows.
+type Reorderer struct {
+	prob      float64
+	path      []Clock
+	flow      map[FlowID]bool
+	rand      *rand.Rand
+	held      map[FlowID]Packet
+	highest   map[FlowID]Seq
+	total     int
+	reordered int
+}
+
+// reorderRelease is used as timer data to release a held packet.
+type reorderRelease struct {
+	Flow FlowID
+	Seq  Seq
+}
+
+// NewReorderSwap returns a new Reorderer that swaps a packet with the flow's
+// next packet, with the given probability, for the given flows, or all flows
+// if none are given.
+func NewReorderSwap(prob float64, flows ...FlowID) *Reorderer {
+	return newReorderer(prob, nil, flows)
+}
+
+// NewReorderMultipath returns a new Reorderer that sends packets over paths
+// with the given extra delays, chosen at random, for the given flows, or all
+// flows if none are given.
+func NewReorderMultipath(path []Clock, flows ...FlowID) *Reorderer {
+	return newReorderer(0, path, flows)
+}
+
+// newReorderer returns a new Reorderer.
+func newReorderer(prob float64, path []Clock, flows []FlowID) *Reorderer {
+	var f map[FlowID]bool
+	if len(flows) > 0 {
+		f = make(map[FlowID]bool)
+		for _, i := range flows {
+			f[i] = true
+		}
+	}
+	return &Reorderer{
+		prob,                              // prob
+		path,                              // path
+		f,                                 // flow
+		rand.New(rand.NewSource(AQMSeed)), // rand
+		make(map[FlowID]Packet),           // held
+		make(map[FlowID]Seq),              // highest
+		0,                                 // total
+		0,                                 // reordered
+	}
+}
+
+// Handle implements Handler.
+func (r *Reorderer) Handle(pkt Packet, node Node) error {
+	if pkt.ACK || pkt.SYN || (r.flow != nil && !r.flow[pkt.Flow]) {
+		node.Send(pkt)
+		return nil
+	}
+	r.total++
+	if len(r.path) > 0 {
+		node.Timer(r.path[r.rand.Intn(len(r.path))], pkt)
+		return nil
+	}
+	if h, ok := r.held[pkt.Flow]; ok {
+		delete(r.held, pkt.Flow)
+		r.send(pkt, node)
+		r.send(h, node)
+		return nil
+	}
+	if r.rand.Float64() < r.prob {
+		r.held[pkt.Flow] = pkt
+		node.Timer(ReorderMaxHold, reorderRelease{pkt.Flow, pkt.Seq})
+		return nil
+	}
+	r.send(pkt, node)
+	return nil
+}
+
+// Ding implements Dinger.
+func (r *Reorderer) Ding(data any, node Node) error {
+	switch v := data.(type) {
+	case Packet:
+		r.send(v, node)
+	case reorderRelease:
+		if h, ok := r.held[v.Flow]; ok && h.Seq == v.Seq {
+			delete(r.held, v.Flow)
+			r.send(h, node)
+		}
+	}
+	return nil
+}
+
+// send sends a packet, counting it as reordered if a later packet from the
+// same flow was already sent.
+func (r *Reorderer) send(pkt Packet, node Node) {
+	if h, ok := r.highest[pkt.Flow]; ok && pkt.Seq < h {
+		r.reordered++
+	} else {
+		r.highest[pkt.Flow] = pkt.Seq
+	}
+	node.Send(pkt)
+}
+
+// Stop implements Stopper.
+func (r *Reorderer) Stop(node Node) error {
+	node.Logf("reorderer: reordered %d of %d packets", r.reordered, r.total)
+	return nil
+}
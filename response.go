@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"math"
@@ -16,6 +16,24 @@ type Responder interface {
 // SCE_MD is the multiplicative decrease for the SCE MD-Scaling response.
 var SCE_MD = math.Pow(CEMD, 1.0/Tau)
 
+// MDScaling contains the MD-Scaling parameters for a flow.  DefaultMDScaling
+// contains the defaults.
+type MDScaling struct {
+	Tau  int     // SCE-MD scale factor
+	CEMD float64 // MD done on CE (or drop) during CA
+}
+
+// SCEMD returns the multiplicative decrease for the SCE MD-Scaling response,
+// such that Tau SCE marks reduce cwnd as much as one CE mark.
+func (m MDScaling) SCEMD() float64 {
+	return math.Pow(m.CEMD, 1.0/float64(m.Tau))
+}
+
+// ceMD implements ceMDer.
+func (m MDScaling) ceMD() float64 {
+	return m.CEMD
+}
+
 // MD is a generic multiplicative decrease Responder.
 type MD float64
 
@@ -25,18 +43,52 @@ func (m MD) Respond(flow *Flow, node Node) (cwnd Bytes) {
 	return
 }
 
+// ScaledMD is the MD-Scaling SCE response, for CCAs that take MD-Scaling
+// parameters (Reno, Reno2, Scalable and CUBIC).  The CCA gives it its own CE
+// MD and Tau, so the SCE response follows them for each flow, and the
+// MDScaling field need not be set.
+type ScaledMD struct {
+	MDScaling
+}
+
+// Respond implements Responder.
+func (m ScaledMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
+	return MD(m.SCEMD()).Respond(flow, node)
+}
+
+// sceResponder returns the SCE Responder for a CCA with the given MD-Scaling
+// parameters, which are set in the Responder if it's a ScaledMD.
+func sceResponder(sce Responder, mds MDScaling) Responder {
+	if _, ok := sce.(ScaledMD); ok {
+		return ScaledMD{mds}
+	}
+	return sce
+}
+
+// AlphaMD is a Responder that performs a DCTCP style multiplicative decrease by
+// half the fraction of marked bytes (alpha).
+type AlphaMD struct {
+}
+
+// Respond implements Responder.
+func (AlphaMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
+	cwnd = Bytes(float64(flow.cwnd) * (1.0 - flow.alpha/2))
+	return
+}
+
 // RateFairMD is a Responder that performs an MD-Scaling multiplicative decrease
 // that results in rate independent fairness with other MD-Scaling flows.
 // Note that for this to work precisely, Reno must increase its CWND once per
 // RTT, rather than once per acked window.
 type RateFairMD struct {
 	MD         float64
+	Tau        int
 	NominalRTT Clock
 }
 
 // Respond implements Responder.
 func (r RateFairMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
-	t := float64(Tau) * math.Pow(float64(flow.srtt), 2) /
+	t := float64(r.Tau) * math.Pow(float64(flow.srtt), 2) /
 		math.Pow(float64(r.NominalRTT), 2)
 	m := math.Pow(r.MD, 1.0/t)
 	cwnd = Bytes(float64(flow.cwnd) * m)
@@ -47,12 +99,13 @@ func (r RateFairMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
 // decrease that is a mild bias away from full RTT dependence.
 type MildFairMD struct {
 	MD         float64
+	Tau        int
 	NominalRTT Clock
 }
 
 // Respond implements Responder.
 func (m MildFairMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
-	t := float64(Tau) * math.Sqrt(float64(flow.srtt)/float64(m.NominalRTT))
+	t := float64(m.Tau) * math.Sqrt(float64(flow.srtt)/float64(m.NominalRTT))
 	md := math.Pow(m.MD, 1.0/t)
 	cwnd = Bytes(float64(flow.cwnd) * md)
 	return
@@ -64,12 +117,13 @@ func (m MildFairMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
 // increase its CWND once per RTT, rather than once per acked window.
 type HybridFairMD struct {
 	MD         float64
+	Tau        int
 	NominalRTT Clock
 }
 
 // Respond implements Responder.
 func (h HybridFairMD) Respond(flow *Flow, node Node) (cwnd Bytes) {
-	t := float64(Tau) * float64(flow.srtt) / float64(h.NominalRTT)
+	t := float64(h.Tau) * float64(flow.srtt) / float64(h.NominalRTT)
 	m := math.Pow(h.MD, 1.0/t)
 	cwnd = Bytes(float64(flow.cwnd) * m)
 	return
@@ -103,6 +157,26 @@ func (TargetCWND) Respond(flow *Flow, node Node) (cwnd Bytes) {
 	return
 }
 
+// RateBDP responds by setting cwnd to the BDP from the latest delivery rate
+// sample and the min RTT, or leaves cwnd unchanged if the sample is invalid or
+// app-limited.
+type RateBDP struct {
+}
+
+// Respond implements Responder.
+func (RateBDP) Respond(flow *Flow, node Node) (cwnd Bytes) {
+	cwnd = flow.cwnd
+	if flow.rs.deliveryRate == 0 || flow.rs.appLimited {
+		return
+	}
+	cwnd = Bytes(flow.rs.deliveryRate.Yps() *
+		time.Duration(flow.minRtt).Seconds())
+	node.Logf("rate bdp cwnd:%d cwnd0:%d rate:%.3fMbps minRtt:%.2fms",
+		cwnd, flow.cwnd, flow.rs.deliveryRate.Mbps(),
+		time.Duration(flow.minRtt).Seconds()*1000)
+	return
+}
+
 // TargetResponse responds by using CWND targeting followed by a regular SCE
 // response.
 type TargetResponse struct {
@@ -110,17 +184,16 @@ type TargetResponse struct {
 
 // Respond implements Responder.
 func (TargetResponse) Respond(flow *Flow, node Node) (cwnd Bytes) {
-	//cwnd0 := flow.cwnd
+	cwnd0 := flow.cwnd
 	flight := flow.inFlightWin.at(node.Now() - flow.srtt)
 	//cwnd = flight * Bytes(flow.minRtt+flow.srtt) / Bytes(2*flow.srtt)
 	cwnd = flight * Bytes(flow.minRtt) / Bytes(flow.srtt)
 	m := 1.0 - math.Sqrt(float64(cwnd))/float64(cwnd)
 	cwnd = Bytes(float64(cwnd) * m)
 	//cwnd = Bytes(float64(cwnd) * float64(SCE_MD))
-	//node.Logf("approach cwnd:%d cwnd0:%d flight:%d minRtt:%.2fms srtt:%.2fms",
-	//	cwnd, cwnd0, flight,
-	//	time.Duration(flow.minRtt).Seconds()*1000,
-	//	time.Duration(flow.srtt).Seconds()*1000)
+	flow.traceState(node, "response", "approach", "cwnd", cwnd,
+		"cwnd0", cwnd0, "flight", flight, "min_rtt", flow.minRtt,
+		"srtt", flow.srtt)
 	return
 }
 
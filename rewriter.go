@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"math/rand"
+)
+
+// ECNRewrite is a set of ECN rewrite operations, which may be combined.
+type ECNRewrite int
+
+const (
+	ClearECT   ECNRewrite = 1 << iota // any codepoint to Not-ECT (bleaching)
+	ClearCE                           // CE to ECT(0)
+	ClearSCE                          // ECT(1) to ECT(0) for SCE capable packets
+	ECT1ToECT0                        // ECT(1) to ECT(0) for all packets
+	ClearECE                          // strip ECE and AccECN counters from ACKs
+	ClearESCE                         // strip ESCE and AccurateSCE counters
+)
+
+// ECNRewriter is a Handler that rewrites ECN state as a middlebox might.  Data
+// packets have their ECN field rewritten, and ACKs have their ECN feedback
+// stripped, so the ECNRewriter may be placed on either the forward or return
+// path.  Rewrites may be limited to the given flows, and are applied with the
+// given probability.
+type ECNRewriter struct {
+	rewrite ECNRewrite
+	prob    float64
+	flow    map[FlowID]bool
+	rand    *rand.Rand
+	total   int
+	changed int
+}
+
+// NewECNRewriter returns a new ECNRewriter that applies the given rewrite with
+// the given probability to packets for the given flows, or all flows if none
+// are given.
+func NewECNRewriter(rewrite ECNRewrite, prob float64,
+	flows ...FlowID) *ECNRewriter {
+	var f map[FlowID]bool
+	if len(flows) > 0 {
+		f = make(map[FlowID]bool)
+		for _, i := range flows {
+			f[i] = true
+		}
+	}
+	return &ECNRewriter{
+		rewrite,                           // rewrite
+		prob,                              // prob
+		f,                                 // flow
+		rand.New(rand.NewSource(AQMSeed)), // rand
+		0,                                 // total
+		0,                                 // changed
+	}
+}
+
+// Handle implements Handler.
+func (r *ECNRewriter) Handle(pkt Packet, node Node) error {
+	r.total++
+	if (r.flow == nil || r.flow[pkt.Flow]) && r.rand.Float64() < r.prob {
+		p := pkt
+		if pkt.ACK {
+			r.rewriteACK(&pkt)
+		} else {
+			r.rewriteData(&pkt)
+		}
+		if p != pkt {
+			r.changed++
+		}
+	}
+	node.Send(pkt)
+	return nil
+}
+
+// rewriteData rewrites the ECN field of a data packet.
+func (r *ECNRewriter) rewriteData(pkt *Packet) {
+	if r.rewrite&ClearECT != 0 {
+		pkt.ECN = NotECT
+	}
+	if r.rewrite&ClearCE != 0 && pkt.ECN == CE {
+		pkt.ECN = ECT0
+	}
+	if r.rewrite&ClearSCE != 0 && pkt.IsSCE() {
+		pkt.ECN = ECT0
+	}
+	if r.rewrite&ECT1ToECT0 != 0 && pkt.ECN == ECT1 {
+		pkt.ECN = ECT0
+	}
+}
+
+// rewriteACK strips ECN feedback from an ACK.  For AccECN and AccurateSCE, the
+// counters are removed, as if the option were stripped, so the Sender sees the
+// ACK as carrying no feedback, and catches up on the next ACK that gets
+// through.
+func (r *ECNRewriter) rewriteACK(pkt *Packet) {
+	if r.rewrite&ClearECE != 0 {
+		pkt.ECE = false
+		pkt.AccECN = false
+		pkt.CEPkts = 0
+		pkt.CEBytes = 0
+	}
+	if r.rewrite&ClearESCE != 0 {
+		pkt.ESCE = false
+		pkt.AccSCE = false
+		pkt.SCEPkts = 0
+		pkt.SCEBytes = 0
+	}
+}
+
+// Stop implements Stopper.
+func (r *ECNRewriter) Stop(node Node) error {
+	node.Logf("ecn rewriter: rewrote %d of %d packets", r.changed, r.total)
+	return nil
+}
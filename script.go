@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/heistp/scim/cc"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// script runs a Lua script that implements a CCA or Responder, so new ideas may
+// be tried without recompiling.  The script is loaded when the simulation is
+// configured, and each flow has its own Lua state, so globals may be used for
+// CCA state.  Before each call, the global table flow is updated with the
+// flow's state, with times in seconds, rates in bits per second and byte
+// counts in bytes:
+//
+//	flow.id, flow.now, flow.mss, flow.cwnd, flow.in_flight, flow.rtt,
+//	flow.srtt, flow.min_rtt, flow.max_rtt, flow.pacing_rate, flow.seq,
+//	flow.acked, flow.signal_next, flow.alpha, flow.delivery_rate,
+//	flow.app_limited
+//
+// and the following functions are available to CCAs:
+//
+//	set_cwnd(bytes), set_pacing_rate(bps), end_signal_window(), log(string)
+//
+// For CCAs, the script may define the functions grow(acked),
+// handle_ce(), handle_sce(), update_rtt(rtt), slow_start_exit() and
+// handle_rate_sample(), which are called when present.  For Responders, the
+// script must define respond(), which returns the new cwnd in bytes.
+type script struct {
+	path  string
+	state *lua.LState
+	flow  *lua.LTable
+	view  cc.FlowState
+}
+
+// newScript returns a new script, loaded from the given path.  It panics if
+// the script fails to load, as this happens during configuration.
+func newScript(path string) *script {
+	s := &script{
+		path,           // path
+		lua.NewState(), // state
+		nil,            // flow
+		nil,            // view
+	}
+	s.flow = s.state.NewTable()
+	s.state.SetGlobal("flow", s.flow)
+	s.register("set_cwnd", func(f cc.Flow, l *lua.LState) {
+		f.SetCwnd(int64(l.CheckNumber(1)))
+	})
+	s.register("set_pacing_rate", func(f cc.Flow, l *lua.LState) {
+		f.SetPacingRate(int64(l.CheckNumber(1)))
+	})
+	s.register("end_signal_window", func(f cc.Flow, l *lua.LState) {
+		f.EndSignalWindow()
+	})
+	s.register("log", func(f cc.Flow, l *lua.LState) {
+		f.Logf("flow:%d %s", f.ID(), l.CheckString(1))
+	})
+	if err := s.state.DoFile(path); err != nil {
+		panic(fmt.Sprintf("script %s: %s", path, err))
+	}
+	return s
+}
+
+// register registers a global function that may only be called by CCAs.
+func (s *script) register(name string, fn func(cc.Flow, *lua.LState)) {
+	s.state.SetGlobal(name, s.state.NewFunction(func(l *lua.LState) int {
+		f, ok := s.view.(cc.Flow)
+		if !ok {
+			l.RaiseError("%s may not be called from a Responder", name)
+		}
+		fn(f, l)
+		return 0
+	}))
+}
+
+// call calls the named global function, if it exists, with the given flow and
+// arguments, and returns the function's return value.  It panics if the call
+// fails.
+func (s *script) call(name string, view cc.FlowState,
+	args ...lua.LValue) (ret lua.LValue, ok bool) {
+	fn := s.state.GetGlobal(name)
+	if fn.Type() != lua.LTFunction {
+		return
+	}
+	s.view = view
+	s.update(view)
+	p := lua.P{Fn: fn, NRet: 1, Protect: true}
+	if err := s.state.CallByParam(p, args...); err != nil {
+		panic(fmt.Sprintf("script %s: %s", s.path, err))
+	}
+	ret = s.state.Get(-1)
+	s.state.Pop(1)
+	s.view = nil
+	ok = true
+	return
+}
+
+// update updates the flow table from the given flow.
+func (s *script) update(view cc.FlowState) {
+	t := s.flow
+	rs := view.RateSample()
+	t.RawSetString("id", lua.LNumber(view.ID()))
+	t.RawSetString("now", seconds(view.Now()))
+	t.RawSetString("mss", lua.LNumber(view.MSS()))
+	t.RawSetString("cwnd", lua.LNumber(view.Cwnd()))
+	t.RawSetString("in_flight", lua.LNumber(view.InFlight()))
+	t.RawSetString("rtt", seconds(view.RTT()))
+	t.RawSetString("srtt", seconds(view.SRTT()))
+	t.RawSetString("min_rtt", seconds(view.MinRTT()))
+	t.RawSetString("max_rtt", seconds(view.MaxRTT()))
+	t.RawSetString("pacing_rate", lua.LNumber(view.PacingRate()))
+	t.RawSetString("seq", lua.LNumber(view.Seq()))
+	t.RawSetString("acked", lua.LNumber(view.ACKed()))
+	t.RawSetString("signal_next", lua.LNumber(view.SignalNext()))
+	t.RawSetString("alpha", lua.LNumber(view.Alpha()))
+	t.RawSetString("delivery_rate", lua.LNumber(rs.DeliveryRate))
+	t.RawSetString("app_limited", lua.LBool(rs.AppLimited))
+}
+
+// seconds returns a duration in seconds, as a Lua number.
+func seconds(d time.Duration) lua.LNumber {
+	return lua.LNumber(d.Seconds())
+}
+
+// scriptCCA implements cc.CCA with a script.
+type scriptCCA struct {
+	*script
+}
+
+// NewScriptCCA returns a new CCA implemented by the Lua script at the given
+// path.
+func NewScriptCCA(path string) *PluginCCA {
+	return NewPluginCCA(scriptCCA{newScript(path)})
+}
+
+// Grow implements cc.CCA.
+func (s scriptCCA) Grow(acked int64, flow cc.Flow) {
+	s.call("grow", flow, lua.LNumber(acked))
+}
+
+// HandleCE implements cc.CEHandler.
+func (s scriptCCA) HandleCE(flow cc.Flow) {
+	s.call("handle_ce", flow)
+}
+
+// HandleSCE implements cc.SCEHandler.
+func (s scriptCCA) HandleSCE(flow cc.Flow) {
+	s.call("handle_sce", flow)
+}
+
+// UpdateRTT implements cc.RTTUpdater.
+func (s scriptCCA) UpdateRTT(rtt time.Duration, flow cc.Flow) {
+	s.call("update_rtt", flow, seconds(rtt))
+}
+
+// HandleRateSample implements cc.RateSampleHandler.
+func (s scriptCCA) HandleRateSample(rs cc.RateSample, flow cc.Flow) {
+	s.call("handle_rate_sample", flow)
+}
+
+// SlowStartExit implements cc.SlowStartExiter.
+func (s scriptCCA) SlowStartExit(flow cc.Flow) {
+	s.call("slow_start_exit", flow)
+}
+
+// scriptResponder implements cc.Responder with a script.
+type scriptResponder struct {
+	*script
+}
+
+// NewScriptResponder returns a new Responder implemented by the Lua script at
+// the given path.
+func NewScriptResponder(path string) PluginResponder {
+	s := newScript(path)
+	if s.state.GetGlobal("respond").Type() != lua.LTFunction {
+		panic(fmt.Sprintf("script %s: respond function not defined", path))
+	}
+	return NewPluginResponder(scriptResponder{s})
+}
+
+// Respond implements cc.Responder.
+func (s scriptResponder) Respond(flow cc.FlowState) (cwnd int64) {
+	r, _ := s.call("respond", flow)
+	n, ok := r.(lua.LNumber)
+	if !ok {
+		panic(fmt.Sprintf("script %s: respond returned %s, not a number",
+			s.path, r.Type()))
+	}
+	return int64(n)
+}
@@ -0,0 +1,9 @@
+-- A scripted Responder (see script.go), which sets cwnd to the BDP from the
+-- latest delivery rate sample and the min RTT, like the built-in RateBDP.
+
+function respond()
+	if flow.delivery_rate == 0 or flow.app_limited then
+		return flow.cwnd
+	end
+	return flow.delivery_rate / 8 * flow.min_rtt
+end
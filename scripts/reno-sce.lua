@@ -0,0 +1,44 @@
+-- Reno-SCE, as a scripted CCA (see script.go).  This approximates the built-in
+-- Reno with RMD, with time-based growth, a multiplicative decrease of ce_md on
+-- CE, and a decrease of ce_md^(1/tau) on each SCE, up to tau times per SRTT.
+
+ce_md = 0.5
+tau = 64
+sce_md = ce_md ^ (1 / tau)
+
+prior_growth = 0
+sce_history = {}
+
+-- grow is called for each ACK that carries no congestion signal.
+function grow(acked)
+	if flow.now - prior_growth > flow.srtt then
+		set_cwnd(flow.cwnd + flow.mss)
+		prior_growth = flow.now
+	end
+end
+
+-- handle_ce is called for each ACK with ECE set.
+function handle_ce()
+	if flow.acked > flow.signal_next then
+		set_cwnd(flow.cwnd * ce_md)
+		end_signal_window()
+	end
+end
+
+-- handle_sce is called for each ACK with ESCE set.
+function handle_sce()
+	local h = {}
+	for _, t in ipairs(sce_history) do
+		if t > flow.now - flow.srtt then
+			table.insert(h, t)
+		end
+	end
+	sce_history = h
+	if #sce_history >= tau then
+		return
+	end
+	table.insert(sce_history, flow.now)
+	if flow.acked > flow.signal_next then
+		set_cwnd(flow.cwnd * sce_md)
+	end
+end
@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"fmt"
@@ -9,17 +9,16 @@ import (
 	"time"
 )
 
-// FlowID represents the ID of a flow.
-type FlowID int
-
 // Sender approximates a TCP sender with multiple flows.
 type Sender struct {
 	flow     []Flow
+	host     *hostQueue
 	schedule []FlowAt
 	inFlight Xplot
 	cwnd     Xplot
 	rtt      Xplot
 	pacing   Xplot
+	tracer   Tracer
 }
 
 // FlowAt is used to mark flows active or inactive to start and stop them.
@@ -31,8 +30,13 @@ type FlowAt struct {
 
 // NewSender returns a new Sender.
 func NewSender(schedule []FlowAt) *Sender {
+	var h *hostQueue
+	if UseHostQdisc != nil {
+		h = newHostQueue(UseHostQdisc, HostRate, Flows)
+	}
 	return &Sender{
 		Flows,
+		h,
 		schedule,
 		Xplot{
 			Title: "Data in-flight",
@@ -75,6 +79,7 @@ func NewSender(schedule []FlowAt) *Sender {
 			},
 			Decimation: PlotPacingInterval,
 		},
+		Tracer{},
 	}
 }
 
@@ -100,11 +105,18 @@ func (s *Sender) Start(node Node) (err error) {
 			return
 		}
 	}
+	if Trace != TraceNone {
+		if err = s.tracer.Open("trace.jsonl", Trace); err != nil {
+			return
+		}
+	}
 	for _, a := range s.schedule {
 		node.Timer(a.At, a)
 	}
 	for i := range s.flow {
 		f := &s.flow[i]
+		f.tracer = &s.tracer
+		f.host = s.host
 		if err = f.Start(node); err != nil {
 			return
 		}
@@ -123,7 +135,7 @@ func (s *Sender) Handle(pkt Packet, node Node) error {
 	if PlotCwnd {
 		l := s.flow[pkt.Flow].inFlight
 		c := s.flow[pkt.Flow].cwnd
-		if PlotCwndLimit && l+MSS > c {
+		if PlotCwndLimit && l+f.mss() > c {
 			s.cwnd.PlotX(node.Now(), c, color(pkt.Flow))
 		} else {
 			s.cwnd.Dot(node.Now(), c, color(pkt.Flow))
@@ -155,6 +167,8 @@ func (s *Sender) Ding(data any, node Node) error {
 	case FlowAt:
 		f := &s.flow[v.ID]
 		f.setActive(v.Active, node)
+	case hostTransmit:
+		s.host.ding(v, node)
 	}
 	return nil
 }
@@ -179,6 +193,10 @@ func (s *Sender) Stop(node Node) (err error) {
 			return
 		}
 	}
+	if s.host != nil {
+		s.host.log(node)
+	}
+	err = s.tracer.Close()
 	return
 }
 
@@ -187,9 +205,10 @@ type Flow struct {
 	id     FlowID
 	active bool
 	open   bool
-	pacing PacingEnabled
+	pacing PacingOptions
 	ecn    ECNCapable
 	sce    SCECapable
+	mtu    Bytes
 
 	seq         Seq // SND.NXT
 	receiveNext Seq // RCV.NXT
@@ -200,20 +219,44 @@ type Flow struct {
 	minRtt      Clock
 	maxRtt      Clock
 
+	alpha       float64 // fraction of marked bytes, as in DCTCP
+	alphaAcked  Bytes
+	alphaMarked Bytes
+	alphaNext   Seq
+	cePkts      int   // AccECN CE packet counter
+	ceBytes     Bytes // AccECN CE byte counter
+	scePkts     int   // SCE packet counter, for AccurateSCE
+	newlySCE    int   // SCE marked segments newly reported by the last ACK
+
+	delivered     Bytes // delivery rate estimation state
+	deliveredCE   Bytes
+	deliveredTime Clock
+	firstSent     Clock
+	appLimited    Bytes // delivered at end of app-limited period, or 0
+	rs            rateSample
+
 	slowStart     SlowStart
 	slowStartExit Responder
 
 	cca         CCA
 	cwnd        Bytes
 	cwndWin     bytesWindow
+	cause       cwndCause // cause of cwnd changes, for attribution
+	cwndCauses  cwndAttribution
 	inFlight    Bytes
 	inFlightWin bytesWindow
+	host        *hostQueue // host queue, or nil if none
+	hostQueued  Bytes      // bytes in the host queue, for TSQ
+	hostRefused bool       // true if refused by a full host qdisc
+	reorder     reorderDetector
 
 	pacingWait    bool
 	pacingSSRatio float64
 	pacingCARatio float64
 	pacingRate    Bitrate
 
+	tracer *Tracer
+
 	seqPlot       Xplot
 	sentPlot      Xplot
 	sent          Bytes
@@ -237,36 +280,50 @@ const (
 	FlowStateCA        // congestion avoidance
 )
 
-// Seq is a sequence number.  For convenience, we use 64 bits.
-type Seq int64
-
-// ECNCapable represents whether a Flow is ECN capable or not.
-type ECNCapable bool
-
-const (
-	ECN   ECNCapable = true
-	NoECN            = false
-)
-
-// ECNCapable represents whether a Flow is SCE capable or not.
-type SCECapable bool
+// ECNCapable represents whether a Flow is ECN capable or not, and if so, which
+// ECT codepoint it sends.
+type ECNCapable int
 
 const (
-	SCE   SCECapable = true
-	NoSCE            = false
+	NoECN ECNCapable = iota // Not-ECT
+	ECN                     // ECT(0)
+	L4S                     // ECT(1), the L4S identifier (RFC 9331)
 )
 
-// PacingEnabled represents whether pacing is enabled or not.
-type PacingEnabled bool
+// codepoint returns the ECN codepoint sent for the ECNCapable value.
+func (e ECNCapable) codepoint() ECNCodepoint {
+	switch e {
+	case ECN:
+		return ECT0
+	case L4S:
+		return ECT1
+	}
+	return NotECT
+}
 
-const (
-	Pacing   PacingEnabled = true
-	NoPacing               = false
-)
+// PacingOptions contains the pacing options for a Flow.  Pacing and NoPacing
+// contain the defaults, with pacing enabled and disabled.
+//
+// With a Burst greater than one, bursts of up to Burst segments are sent back
+// to back per pacing interval, as with a pacing quantum or TSO, and the
+// interval is lengthened to keep the same average rate.  Bursts are deferred
+// while cwnd has too little space, as with TSO deferral.  With TSOAutosize,
+// the burst size is the pacing rate times PacingTSOAutosizeTime, up to
+// PacingTSOMaxBytes, and at least Burst segments, as with Linux TSO
+// autosizing and sch_fq.
+type PacingOptions struct {
+	Enabled     bool    // if true, pacing is enabled
+	SSRatio     float64 // pacing rate / (cwnd / srtt) in slow-start
+	CARatio     float64 // pacing rate / (cwnd / srtt) in CA
+	Burst       int     // segments per pacing interval, or min for TSOAutosize
+	TSOAutosize bool    // if true, size bursts from the pacing rate
+}
 
 // NewFlow returns a new flow.
 func NewFlow(id FlowID, ecn ECNCapable, sce SCECapable, ss SlowStart,
-	ssExit Responder, cca CCA, pacing PacingEnabled, active bool) Flow {
+	ssExit Responder, cca CCA, pacing PacingOptions, active bool) Flow {
+	mtu := flowMTU(id)
+	iw := IWSegs * (mtu - HeaderLen)
 	return Flow{
 		id,                   // id
 		active,               // active
@@ -274,6 +331,7 @@ func NewFlow(id FlowID, ecn ECNCapable, sce SCECapable, ss SlowStart,
 		pacing,               // pacing
 		ecn,                  // ecn
 		sce,                  // sce
+		mtu,                  // mtu
 		0,                    // seq
 		0,                    // receiveNext
 		0,                    // signalNext
@@ -282,17 +340,38 @@ func NewFlow(id FlowID, ecn ECNCapable, sce SCECapable, ss SlowStart,
 		0,                    // srtt
 		ClockMax,             // minRtt
 		0,                    // maxRtt
+		DCTCPAlphaInit,       // alpha
+		0,                    // alphaAcked
+		0,                    // alphaMarked
+		0,                    // alphaNext
+		0,                    // cePkts
+		0,                    // ceBytes
+		0,                    // scePkts
+		0,                    // newlySCE
+		0,                    // delivered
+		0,                    // deliveredCE
+		0,                    // deliveredTime
+		0,                    // firstSent
+		0,                    // appLimited
+		rateSample{},         // rs
 		ss,                   // slowStart
 		ssExit,               // slowStartExit
 		cca,                  // cca
-		IW,                   // cwnd
+		iw,                   // cwnd
 		bytesWindow{},        // cwndWin
+		causeNone,            // cause
+		newCwndAttribution(), // cwndCauses
 		0,                    // inFlight
 		bytesWindow{},        // inFlightWindow
+		nil,                  // host
+		0,                    // hostQueued
+		false,                // hostRefused
+		newReorderDetector(), // reorder
 		false,                // pacingWait
-		DefaultPacingSSRatio, // pacingSSRatio
-		DefaultPacingCARatio, // pacingCARatio
+		pacing.SSRatio,       // pacingSSRatio
+		pacing.CARatio,       // pacingCARatio
 		0,                    // pacingRate
+		nil,                  // tracer
 		Xplot{
 			Title: "Sequence Numbers - send:red ack:white",
 			X: Axis{
@@ -356,13 +435,27 @@ func NewFlow(id FlowID, ecn ECNCapable, sce SCECapable, ss SlowStart,
 
 // AddFlow adds a flow with an ID from the global flowID.
 func AddFlow(ecn ECNCapable, sce SCECapable, ss SlowStart, ssExit Responder,
-	cca CCA, pacing PacingEnabled, active bool) (
+	cca CCA, pacing PacingOptions, active bool) (
 	flow Flow) {
 	i := flowID
 	flowID++
 	return NewFlow(i, ecn, sce, ss, ssExit, cca, pacing, active)
 }
 
+// flowMTU returns the MTU for the given flow from FlowMTU, or the default MTU
+// if it's not listed or is 0.
+func flowMTU(id FlowID) Bytes {
+	if int(id) < len(FlowMTU) && FlowMTU[id] > 0 {
+		return FlowMTU[id]
+	}
+	return MTU
+}
+
+// mss returns the flow's maximum segment size.
+func (f *Flow) mss() Bytes {
+	return f.mtu - HeaderLen
+}
+
 // FlowID is the currently assigned flow ID, incremented as flows are added.
 var flowID FlowID = 0
 
@@ -394,6 +487,12 @@ func (f *Flow) Start(node Node) (err error) {
 			return
 		}
 	}
+	if CwndAttribution && CwndTimelineInterval > 0 {
+		n := fmt.Sprintf("cwnd-causes.%d.tsv", f.id)
+		if err = f.cwndCauses.Open(n); err != nil {
+			return
+		}
+	}
 	if s, ok := f.slowStart.(Starter); ok {
 		if err = s.Start(node); err != nil {
 			return
@@ -420,6 +519,15 @@ func (f *Flow) Stop(node Node) (err error) {
 		f.accelPlot.Close()
 		f.accel2Plot.Close()
 	}
+	if DetectReordering {
+		f.reorder.log(f, node)
+	}
+	if CwndAttribution {
+		f.cwndCauses.log(f.id, node)
+		if err = f.cwndCauses.Close(node.Now()); err != nil {
+			return
+		}
+	}
 	if s, ok := f.slowStart.(Stopper); ok {
 		if err = s.Stop(node); err != nil {
 			return
@@ -436,6 +544,9 @@ func (f *Flow) Stop(node Node) (err error) {
 // setActive sets the active field, and starts sending if active.
 func (f *Flow) setActive(active bool, node Node) {
 	f.active = active
+	if !active {
+		f.setAppLimited()
+	}
 	if active {
 		if !f.open {
 			f.sendPacket(Packet{Len: HeaderLen, SYN: true}, node)
@@ -445,17 +556,17 @@ func (f *Flow) setActive(active bool, node Node) {
 	}
 }
 
-// send sends packets for the flow. If pacing is disabled, it sends packets
-// until in-flight bytes would exceed cwnd. If pacing is enabled, it either
-// returns immediately if pacing is active, or sends a packet and schedules a
-// wait for the next send.
+// send sends packets for the flow, once it's open.  If pacing is disabled, or
+// done by the host qdisc, it sends packets until in-flight bytes would exceed
+// cwnd. If pacing is enabled, it either returns immediately if pacing is
+// active, or sends a packet and schedules a wait for the next send.
 func (f *Flow) send(node Node) {
-	if !f.active {
+	if !f.active || !f.open {
 		return
 	}
 	// no pacing
-	if !f.pacing {
-		for b := true; b; b = f.sendPacket(Packet{Len: MTU}, node) {
+	if !f.pacing.Enabled || f.host.paces() {
+		for b := true; b; b = f.sendPacket(Packet{Len: f.mtu}, node) {
 		}
 		return
 	}
@@ -463,12 +574,30 @@ func (f *Flow) send(node Node) {
 	if f.pacingWait {
 		return
 	}
-	if !f.sendPacket(Packet{Len: MTU}, node) {
+	p, _ := f.slowStart.(pacer)
+	if f.state != FlowStateSS {
+		p = nil
+	}
+	n := f.burst(p != nil)
+	if f.deferBurst(n) {
 		return
 	}
-	d := f.pacingDelay(MTU)
+	var b Bytes
+	for ; n > 0; n-- {
+		if !f.sendPacket(Packet{Len: f.mtu}, node) {
+			break
+		}
+		b += f.mtu
+	}
+	if b == 0 {
+		return
+	}
+	d := f.pacingDelay(b)
+	if p != nil {
+		d = p.pace(f, node)
+	}
 	if d == 0 {
-		for b := true; b; b = f.sendPacket(Packet{Len: MTU}, node) {
+		for b := true; b; b = f.sendPacket(Packet{Len: f.mtu}, node) {
 		}
 		return
 	}
@@ -476,6 +605,33 @@ func (f *Flow) send(node Node) {
 	node.Timer(d, FlowSend(f.id))
 }
 
+// burst returns the number of segments to send per pacing interval.  If
+// perPacket is true, as for slow-starts that pace each packet, it returns 1.
+func (f *Flow) burst(perPacket bool) (n int) {
+	if perPacket {
+		return 1
+	}
+	n = f.pacing.Burst
+	if d := f.pacingDelay(f.mtu); f.pacing.TSOAutosize && d > 0 {
+		b := f.mtu * Bytes(PacingTSOAutosizeTime) / Bytes(d)
+		n = max(n, int(min(b, PacingTSOMaxBytes)/f.mtu))
+	}
+	n = max(n, 1)
+	return
+}
+
+// deferBurst returns true if sending a burst of n segments should be deferred
+// until more of cwnd is available, as with Linux's tcp_tso_should_defer.  It
+// defers while data is in flight, and the space in cwnd is less than both the
+// burst and cwnd / PacingTSOWinDivisor.
+func (f *Flow) deferBurst(n int) bool {
+	if n <= 1 || f.inFlight == 0 {
+		return false
+	}
+	r := f.cwnd - f.inFlight
+	return r < Bytes(n)*f.mtu && r < f.cwnd/PacingTSOWinDivisor
+}
+
 // FlowSend is used as timer data for pacing.
 type FlowSend FlowID
 
@@ -485,12 +641,23 @@ func (f *Flow) sendPacket(pkt Packet, node Node) bool {
 	if f.inFlight+pkt.SegmentLen() > f.cwnd {
 		return false
 	}
+	if f.host != nil && f.host.throttle(f) {
+		return false
+	}
 	pkt.Flow = f.id
 	pkt.Seq = f.seq
-	pkt.ECNCapable = f.ecn
+	pkt.ECN = f.ecn.codepoint()
 	pkt.SCECapable = f.sce
 	pkt.Sent = node.Now()
-	node.Send(pkt)
+	f.onSendRate(&pkt, node.Now())
+	if DetectReordering {
+		f.reorder.onSend(pkt)
+	}
+	if f.host != nil {
+		f.host.enqueue(pkt, f, node)
+	} else {
+		node.Send(pkt)
+	}
 	if PlotSeq {
 		f.seqPlot.Dot(node.Now(), strconv.FormatInt(int64(pkt.Seq), 10),
 			colorRed)
@@ -556,7 +723,7 @@ func (f *Flow) getPacingRate() Bitrate {
 	if f.pacingRate > 0 {
 		return f.pacingRate
 	}
-	return CalcBitrate(MSS, time.Duration(f.pacingDelay(MSS)))
+	return CalcBitrate(f.mss(), time.Duration(f.pacingDelay(f.mss())))
 }
 
 // cwndFromPacingRate returns a CWND corresponding to the current pacing rate.
@@ -613,7 +780,11 @@ func (f *Flow) handleAck(pkt Packet, node Node) {
 	acked := Bytes(pkt.ACKNum - f.receiveNext)
 	f.addInFlight(-acked, node.Now())
 	f.receiveNext = pkt.ACKNum
+	f.cause = causeRTT
 	f.updateRTT(pkt, node)
+	if DetectReordering {
+		f.reorder.onACK(pkt, acked, f, node.Now())
+	}
 	f.acked += acked
 	if PlotSent {
 		f.sentPlot.Dot(node.Now(), strconv.FormatUint(uint64(f.acked), 10),
@@ -647,8 +818,20 @@ func (f *Flow) handleAck(pkt Packet, node Node) {
 				colorWhite)
 		}
 	}
-	// react to congestion signals
+	// process ECN feedback, then react to congestion signals
+	m := f.handleECNFeedback(&pkt, acked)
+	f.sampleRate(pkt, acked, m, node.Now())
+	if PlotRate && f.rs.deliveryRate > 0 {
+		c := colorGreen
+		if f.rs.appLimited {
+			c = colorYellow
+		}
+		f.ratePlot.Dot(node.Now(),
+			strconv.FormatUint(uint64(f.rs.deliveryRate.Yps()), 10), c)
+	}
 	if pkt.ECE {
+		c0, r0, s0 := f.cwnd, f.pacingRate, f.state
+		f.cause = causeCE
 		switch f.state {
 		case FlowStateSS:
 			if h, ok := f.slowStart.(handleCESSer); ok {
@@ -662,22 +845,46 @@ func (f *Flow) handleAck(pkt Packet, node Node) {
 				h.handleCE(f, node)
 			}
 		}
-	} else if pkt.ESCE && f.sce == SCE {
+		f.traceSignal("CE", c0, r0, s0, node)
+	}
+	if pkt.ESCE && f.sce == SCE {
+		c0, r0, s0 := f.cwnd, f.pacingRate, f.state
+		f.cause = causeSCE
+		for i := 0; i < f.newlySCE; i++ {
+			switch f.state {
+			case FlowStateSS:
+				if h, ok := f.slowStart.(handleSCESSer); ok {
+					if h.handleSCE(f, node) {
+						f.exitSlowStart(node, "SCE")
+						f.signalNext = f.seq
+					}
+				}
+			case FlowStateCA:
+				if h, ok := f.cca.(handleSCEer); ok {
+					h.handleSCE(f, node)
+				}
+			}
+		}
+		f.traceSignal("SCE", c0, r0, s0, node)
+	}
+	if f.rs.newlyAcked > 0 {
+		f.cause = causeRateSample
 		switch f.state {
 		case FlowStateSS:
-			if h, ok := f.slowStart.(handleSCESSer); ok {
-				if h.handleSCE(f, node) {
-					f.exitSlowStart(node, "SCE")
+			if h, ok := f.slowStart.(handleRateSampleSSer); ok {
+				if h.handleRateSample(f.rs, f, node) {
+					f.exitSlowStart(node, "RateSample")
 					f.signalNext = f.seq
 				}
 			}
 		case FlowStateCA:
-			if h, ok := f.cca.(handleSCEer); ok {
-				h.handleSCE(f, node)
+			if h, ok := f.cca.(handleRateSampler); ok {
+				h.handleRateSample(f.rs, f, node)
 			}
 		}
 	}
 	if pkt.Telemetry != (Telemetry{}) {
+		f.cause = causeTelemetry
 		switch f.state {
 		case FlowStateSS:
 			if h, ok := f.slowStart.(handleTelemetrySSer); ok {
@@ -694,6 +901,7 @@ func (f *Flow) handleAck(pkt Packet, node Node) {
 		}
 	}
 	// grow cwnd
+	f.cause = causeGrowth
 	switch f.state {
 	case FlowStateSS:
 		if f.slowStart.grow(acked, f, node) {
@@ -703,18 +911,61 @@ func (f *Flow) handleAck(pkt Packet, node Node) {
 	case FlowStateCA:
 		f.cca.grow(acked, pkt, f, node)
 	}
+	f.cause = causeNone
+}
+
+// handleECNFeedback updates the fraction of marked bytes (alpha) from the ECN
+// feedback on the given ACK, once per window of data.  For AccECN feedback,
+// ECE is set on the ACK if the CE packet counter has increased, so the same
+// congestion signal handling applies for either feedback mode.  For classic
+// feedback, all bytes acked by an ACK with ECE are counted as marked.  The
+// number of newly marked bytes is returned.  Likewise, for AccurateSCE
+// feedback, ESCE is set if the SCE packet counter has increased, and newlySCE
+// is set to the number of newly SCE marked segments, or 1 for an ACK with
+// classic ESCE.
+func (f *Flow) handleECNFeedback(pkt *Packet, acked Bytes) (m Bytes) {
+	if pkt.AccECN {
+		m = pkt.CEBytes - f.ceBytes
+		pkt.ECE = pkt.CEPkts > f.cePkts
+		f.ceBytes = pkt.CEBytes
+		f.cePkts = pkt.CEPkts
+	} else if pkt.ECE {
+		m = acked
+	}
+	f.newlySCE = 0
+	if pkt.AccSCE {
+		f.newlySCE = pkt.SCEPkts - f.scePkts
+		pkt.ESCE = f.newlySCE > 0
+		f.scePkts = pkt.SCEPkts
+	} else if pkt.ESCE {
+		f.newlySCE = 1
+	}
+	f.alphaAcked += acked
+	f.alphaMarked += m
+	if f.receiveNext > f.alphaNext && f.alphaAcked > 0 {
+		p := min(float64(f.alphaMarked)/float64(f.alphaAcked), 1)
+		f.alpha = (1-DCTCPGain)*f.alpha + DCTCPGain*p
+		f.alphaAcked = 0
+		f.alphaMarked = 0
+		f.alphaNext = f.seq
+	}
+	return
 }
 
 // exitSlowStart adjusts cwnd for slow-start exit and changes state to CA.
 func (f *Flow) exitSlowStart(node Node, reason string) {
+	c := f.cause
+	f.cause = causeSlowStartExit
 	cwnd0 := f.cwnd
 	f.setCWND(f.slowStartExit.Respond(f, node), node)
 	node.Logf("flow:%d slow-start exit %s cwnd:%d cwnd0:%d",
 		f.id, reason, f.cwnd, cwnd0)
+	f.traceSlowStartExit(reason, cwnd0, node)
 	if x, ok := f.cca.(slowStartExiter); ok {
 		x.slowStartExit(f, node)
 	}
 	f.state = FlowStateCA
+	f.cause = c
 }
 
 // updateRTT updates the RTT and its statistics from the given packet, if its
@@ -751,12 +1002,16 @@ func (f *Flow) updateRTT(pkt Packet, node Node) {
 // setCWND updates the congestion window to the given value and performs
 // clamping so that it doesn't fall below 2x MSS.
 func (f *Flow) setCWND(cwnd Bytes, node Node) {
-	if cwnd < 2*MSS {
-		cwnd = 2 * MSS
+	r := cwnd
+	if cwnd < 2*f.mss() {
+		cwnd = 2 * f.mss()
 	}
+	cwnd0 := f.cwnd
 	f.cwnd = cwnd
 	now := node.Now()
 	f.cwndWin.add(now, f.cwnd, now-2*f.srtt)
+	f.attributeCwnd(cwnd0, r, node)
+	f.traceCwnd(cwnd0, r, node)
 }
 
 // bytesWindow stores a value in bytes over time.
@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package sim
 
 import (
 	"math"
@@ -76,9 +76,10 @@ func (b Bytes) Pebibytes() float64 {
 	return float64(b) / float64(Pebibyte)
 }
 
-// Segments returns the Bytes as a floating point number of MSS-sized segments.
-func (b Bytes) Segments() float64 {
-	return float64(b) / float64(MSS)
+// Segments returns the Bytes as a floating point number of segments of the
+// given MSS.
+func (b Bytes) Segments(mss Bytes) float64 {
+	return float64(b) / float64(mss)
 }
 
 func (b Bytes) String() string {
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package sim
+
+import (
+	"fmt"
+)
+
+// Packet represents a network packet in the simulation, which for now always
+// includes an approximation of a TCP segment.
+type Packet struct {
+	// IP fields
+	Len Bytes
+	ECN ECNCodepoint
+
+	// TCP segment fields
+	Flow   FlowID
+	Seq    Seq
+	ACKNum Seq
+	SYN    bool
+	ACK    bool
+	ECE    bool
+	ESCE   bool
+	Sent   Clock
+
+	// AccECN (RFC 9768) feedback, with cumulative counters of CE marked
+	// packets and payload bytes, valid on ACKs if AccECN is set
+	AccECN  bool
+	CEPkts  int
+	CEBytes Bytes
+
+	// the same style of cumulative counters for SCE marked packets and
+	// payload bytes, valid on ACKs if AccSCE is set
+	AccSCE   bool
+	SCEPkts  int
+	SCEBytes Bytes
+
+	// non-standard fields for simulation purposes
+	Delayed bool
+	// delivery rate estimation state when sent, echoed in ACKs
+	// (draft-cheng-iccrg-delivery-rate-estimation)
+	Delivered     Bytes
+	DeliveredCE   Bytes
+	DeliveredTime Clock
+	FirstSent     Clock
+	TxInFlight    Bytes
+	AppLimited    bool
+	// SCECapable is the sender's SCE capability, which would be negotiated
+	// in the handshake.  SCE AQMs use it to select their response, and the
+	// Receiver to interpret ECT(1) as an SCE mark.
+	SCECapable SCECapable
+
+	// Telemetry is used for simulating telemetry-based CCAs.
+	Telemetry
+
+	// AQM fields
+	Enqueue    Clock
+	EnqueueLen Bytes
+}
+
+// FlowID represents the ID of a flow.
+type FlowID int
+
+// Seq is a sequence number.  For convenience, we use 64 bits.
+type Seq int64
+
+// SCECapable represents whether a Flow is SCE capable or not.
+type SCECapable bool
+
+const (
+	SCE   SCECapable = true
+	NoSCE            = false
+)
+
+// HeaderLen is the length of the IP and TCP headers on each Packet.
+const HeaderLen = 20 + 20 + 12 // IPv4 + TCP + timestamps
+
+// ECNCodepoint is the two-bit ECN field of the IP header (RFC 3168).  The
+// meaning of ECT(1) depends on the interpretation: SCE uses it as a mark on
+// ECT(0) packets (draft-morton-tsvwg-sce), while L4S uses it to identify
+// scalable traffic (RFC 9331).
+type ECNCodepoint uint8
+
+const (
+	NotECT ECNCodepoint = 0b00 // Not ECN-Capable Transport
+	ECT1   ECNCodepoint = 0b01 // ECN-Capable Transport (1)
+	ECT0   ECNCodepoint = 0b10 // ECN-Capable Transport (0)
+	CE     ECNCodepoint = 0b11 // Congestion Experienced
+)
+
+// String implements fmt.Stringer.
+func (e ECNCodepoint) String() string {
+	switch e {
+	case NotECT:
+		return "Not-ECT"
+	case ECT1:
+		return "ECT(1)"
+	case ECT0:
+		return "ECT(0)"
+	case CE:
+		return "CE"
+	}
+	return fmt.Sprintf("ECNCodepoint(%d)", uint8(e))
+}
+
+// ECT returns true if the codepoint is ECN capable (ECT(0), ECT(1) or CE).
+func (e ECNCodepoint) ECT() bool {
+	return e != NotECT
+}
+
+// IsCE returns true if the Packet is CE marked.
+func (p Packet) IsCE() bool {
+	return p.ECN == CE
+}
+
+// IsSCE returns true if the Packet is SCE marked, i.e. it arrived as ECT(1)
+// from an SCE capable sender.
+func (p Packet) IsSCE() bool {
+	return p.ECN == ECT1 && bool(p.SCECapable)
+}
+
+// handleSim implements output.
+func (p Packet) handleSim(sim *Sim, node nodeID) (error, bool) {
+	x := sim.next(node)
+	if sim.State[x] == Running {
+		return nil, false
+	}
+	sim.in[x] <- inputNow{p, sim.now}
+	sim.setState(x, Running)
+	return nil, true
+}
+
+// handleNode implements input.
+func (p Packet) handleNode(node *node) (err error) {
+	return node.handler.Handle(p, node)
+}
+
+// SegmentLen returns the size of the payload (IP length minus header bytes).
+func (p Packet) SegmentLen() Bytes {
+	return p.Len - HeaderLen
+}
+
+// NextSeq returns the next expected sequence number after this Packet.
+func (p Packet) NextSeq() Seq {
+	if p.SYN {
+		return p.Seq + 1
+	}
+	return p.Seq + Seq(p.SegmentLen())
+}
+
+// Telemetry contains data set on packets for telemetry-based CCAs.
+type Telemetry struct {
+	Sojourn Clock // time between enqueue and dequeue
+	QLen    Bytes // queue length in bytes before packet is enqueued
+	DQLen   Bytes // queue length in bytes after packet is dequeued
+	PktLen  Bytes // packet length (could have grown due to encapsulation)
+	Total   Bytes // total bytes sent by bottleneck
+}
+
+// Merge combines newer Telemetry data for delayed ACK logic. The newer values
+// are taken for all fields except PktLen, which is summed. NOTE We could also
+// take average values for sojourn and qlen here.
+func (t Telemetry) Merge(newer Telemetry) Telemetry {
+	return Telemetry{
+		newer.Sojourn,
+		newer.QLen,
+		newer.DQLen,
+		t.PktLen + newer.PktLen,
+		newer.Total,
+	}
+}
@@ -1,15 +1,15 @@
 // SPDX-License-Identifier: GPL-3.0-or-later
 // Copyright 2025 Pete Heist
 
-package main
+package scim
 
 import (
 	"fmt"
 )
 
 // A SlowStart implements slow-start for a sender.  SlowStart implementations
-// may also implement initer, updateRtter, handleCEer or handleSCEer as
-// necessary.
+// may also implement initer, updateRtter, handleCESSer, handleSCESSer,
+// handleTelemetrySSer, handleRateSampleSSer or pacer as necessary.
 type SlowStart interface {
 	grow(acked Bytes, flow *Flow, node Node) (exit bool)
 }
@@ -29,6 +29,18 @@ type handleTelemetrySSer interface {
 	handleTelemetry(Telemetry, *Flow, Node) (exit bool)
 }
 
+// A handleRateSampleSSer can handle the delivery rate sample taken on each
+// ACK, and return true to exit SS.
+type handleRateSampleSSer interface {
+	handleRateSample(rateSample, *Flow, Node) (exit bool)
+}
+
+// A pacer is called after each packet is sent with pacing, and returns the
+// delay until the next packet is sent, overriding the pacing rate.
+type pacer interface {
+	pace(*Flow, Node) (delay Clock)
+}
+
 // An initer an initialize a SlowStart algorithm.
 type initer interface {
 	init(*Flow, Node)
@@ -49,15 +61,25 @@ func (NoSS) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 	return
 }
 
+// StdSSOptions contains the options for StdSS.  DefaultStdSS contains the
+// defaults.
+type StdSSOptions struct {
+	Growth        SSGrowth // growth strategy
+	BaseReduction bool     // if true, reduce the exponential base on SCE
+	ExitThreshold int      // number of SCE marks for exit
+}
+
 // StdSS implements standard slow-start mostly according to RFC 5681.
 type StdSS struct {
+	StdSSOptions
 	sceCtr int
 }
 
 // NewStdSS returns a new StdSS.
-func NewStdSS() *StdSS {
+func NewStdSS(opt StdSSOptions) *StdSS {
 	return &StdSS{
-		0, // sceCtr
+		opt, // StdSSOptions
+		0,   // sceCtr
 	}
 }
 
@@ -70,7 +92,7 @@ func (*StdSS) handleCE(flow *Flow, node Node) (exit bool) {
 // handleSCE implements SlowStart.
 func (s *StdSS) handleSCE(flow *Flow, node Node) (exit bool) {
 	s.sceCtr++
-	exit = s.sceCtr >= DefaultSSExitThreshold
+	exit = s.sceCtr >= s.ExitThreshold
 	return
 }
 
@@ -78,16 +100,16 @@ func (s *StdSS) handleSCE(flow *Flow, node Node) (exit bool) {
 func (s *StdSS) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 	var i Bytes
 	d := 1
-	switch DefaultSSGrowth {
+	switch s.Growth {
 	case SSGrowthNoABC:
-		i = MSS
+		i = flow.mss()
 	case SSGrowthABC1_5:
 		d = 2
 		i = acked
 	case SSGrowthABC2:
 		i = acked
 	}
-	if DefaultSSBaseReduction {
+	if s.BaseReduction {
 		//d += s.sceCtr
 		if s.sceCtr >= len(LeoK) {
 			d += LeoK[len(LeoK)-1]
@@ -98,7 +120,7 @@ func (s *StdSS) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 	if d > 1 {
 		i /= Bytes(d)
 	}
-	if flow.cwnd/Bytes(d) <= MSS {
+	if flow.cwnd/Bytes(d) <= flow.mss() {
 		exit = true
 		return
 	}
@@ -106,8 +128,23 @@ func (s *StdSS) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 	return
 }
 
+// HyStartPPOptions contains the options for HyStartPP.  DefaultHyStartPP
+// contains the defaults.
+type HyStartPPOptions struct {
+	Growth           SSGrowth // growth strategy in standard slow-start
+	ExitThreshold    int      // number of SCE marks for exit
+	MinRTTThresh     Clock    // MIN_RTT_THRESH
+	MaxRTTThresh     Clock    // MAX_RTT_THRESH
+	MinRTTDivisor    Clock    // MIN_RTT_DIVISOR
+	NRTTSample       int      // N_RTT_SAMPLE
+	CSSGrowthDivisor Bytes    // CSS_GROWTH_DIVISOR
+	CSSRounds        int      // CSS_ROUNDS
+	LNoPacing        Bytes    // L, in segments, when pacing is not used
+}
+
 // HyStartPP implements slow-start according to HyStart++ RFC 9406.
 type HyStartPP struct {
+	HyStartPPOptions
 	rtt                Clock
 	lastRoundMinRTT    Clock
 	currentRoundMinRTT Clock
@@ -119,8 +156,10 @@ type HyStartPP struct {
 	sceCtr             int
 }
 
-func NewHyStartPP() *HyStartPP {
+// NewHyStartPP returns a new HyStartPP.
+func NewHyStartPP(opt HyStartPPOptions) *HyStartPP {
 	return &HyStartPP{
+		opt,      // HyStartPPOptions
 		0,        // rtt
 		ClockMax, // lastRoundMinRTT
 		ClockMax, // currentRoundMinRTT
@@ -142,7 +181,7 @@ func (*HyStartPP) handleCE(flow *Flow, node Node) (exit bool) {
 // handleSCE implements SlowStart.
 func (h *HyStartPP) handleSCE(flow *Flow, node Node) (exit bool) {
 	h.sceCtr++
-	exit = h.sceCtr >= DefaultSSExitThreshold
+	exit = h.sceCtr >= h.ExitThreshold
 	return
 }
 
@@ -150,11 +189,11 @@ func (h *HyStartPP) handleSCE(flow *Flow, node Node) (exit bool) {
 func (h *HyStartPP) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 	if !h.conservative {
 		h.hystartRound(flow)
-		if h.rttSampleCount >= HyNRTTSample &&
+		if h.rttSampleCount >= h.NRTTSample &&
 			h.currentRoundMinRTT != ClockMax &&
 			h.lastRoundMinRTT != ClockMax {
-			t := max(HyMinRTTThresh,
-				min(h.lastRoundMinRTT/HyMinRTTDivisor, HyMaxRTTThresh))
+			t := max(h.MinRTTThresh,
+				min(h.lastRoundMinRTT/h.MinRTTDivisor, h.MaxRTTThresh))
 			if h.currentRoundMinRTT >= h.lastRoundMinRTT+t {
 				node.Logf("HyStart: CSS")
 				h.cssBaselineMinRTT = h.currentRoundMinRTT
@@ -163,9 +202,9 @@ func (h *HyStartPP) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 			}
 		}
 		var i Bytes
-		switch DefaultSSGrowth {
+		switch h.Growth {
 		case SSGrowthNoABC:
-			i = MSS
+			i = flow.mss()
 		case SSGrowthABC1_5:
 			i = acked / 2
 		case SSGrowthABC2:
@@ -177,21 +216,21 @@ func (h *HyStartPP) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
 			h.cssRounds++
 			node.Logf("HyStart: CSS rounds %d", h.cssRounds)
 		}
-		if h.rttSampleCount >= HyNRTTSample &&
+		if h.rttSampleCount >= h.NRTTSample &&
 			h.currentRoundMinRTT < h.cssBaselineMinRTT {
 			node.Logf("HyStart: back to SS")
 			h.cssBaselineMinRTT = ClockMax
 			h.conservative = false
-		} else if h.cssRounds >= HyCSSRounds {
+		} else if h.cssRounds >= h.CSSRounds {
 			node.Logf("HyStart: CA")
 			exit = true
 			return
 		}
-		if flow.pacing == NoPacing {
+		if !flow.pacing.Enabled {
 			flow.setCWND(flow.cwnd+
-				min(acked, HyStartLNoPacing*MSS)/HyCSSGrowthDivisor, node)
+				min(acked, h.LNoPacing*flow.mss())/h.CSSGrowthDivisor, node)
 		} else {
-			flow.setCWND(flow.cwnd+acked/HyCSSGrowthDivisor, node)
+			flow.setCWND(flow.cwnd+acked/h.CSSGrowthDivisor, node)
 		}
 	}
 
@@ -220,11 +259,20 @@ func (h *HyStartPP) updateRtt(rtt Clock, flow *Flow, node Node) {
 	h.rtt = rtt
 }
 
+// EsspOptions contains the options for Essp.  DefaultEssp contains the
+// defaults.
+type EsspOptions struct {
+	HalfKExit      bool    // if true, exit earlier, at K(i*2) instead of K(i)
+	DelayThreshold float64 // if > 1, advance stage when sRTT > x * minRTT
+	CWNDTargeting  bool    // if true, target CWND on advance
+}
+
 // Essp is a slow start implementation that reduces both the exponential base
 // and the pacing scaling factor in response to congestion signals and delay.
 //
 // https://github.com/chromi/essp/
 type Essp struct {
+	EsspOptions
 	stage    int
 	ackedRem Bytes
 	rtt      Clock
@@ -232,8 +280,9 @@ type Essp struct {
 }
 
 // NewEssp returns a new Essp.
-func NewEssp() *Essp {
+func NewEssp(opt EsspOptions) *Essp {
 	return &Essp{
+		opt,      // EsspOptions
 		0,        // stage
 		0,        // ackedRem
 		0,        // iRtt
@@ -264,7 +313,7 @@ func (l *Essp) k() int {
 
 // exitK returns the K at which slow-start exit should occur.
 func (l *Essp) exitK() int {
-	if EsspHalfKExit {
+	if l.HalfKExit {
 		return LeoK[l.stage*2]
 	}
 	return LeoK[l.stage]
@@ -283,15 +332,15 @@ func (l *Essp) advance(why string, flow *Flow, node Node) (exit bool) {
 	}
 	c0 := flow.cwnd
 	r0 := flow.getPacingRate()
-	if EsspCWNDTargeting {
+	if l.CWNDTargeting {
 		c := c0 * Bytes(l.minRtt) / Bytes(l.rtt)
 		if flow.cwnd > c {
 			flow.setCWND(c, node)
 		}
 		defer l.resetRtt() // defers to after logging
 	}
-	if exit = Bytes(l.exitK()) >= c0/MSS; exit {
-		flow.pacingSSRatio = DefaultPacingSSRatio
+	if exit = Bytes(l.exitK()) >= c0/flow.mss(); exit {
+		flow.pacingSSRatio = flow.pacing.SSRatio
 	} else {
 		flow.pacingSSRatio = l.scale()
 		flow.signalNext = flow.seq
@@ -314,8 +363,8 @@ func (l *Essp) handleSCE(flow *Flow, node Node) (exit bool) {
 
 // grow implements SlowStart.
 func (l *Essp) grow(acked Bytes, flow *Flow, node Node) (exit bool) {
-	if EsspDelayThreshold > 1.0 && flow.receiveNext > flow.signalNext &&
-		l.rtt > Clock(float64(l.minRtt)*EsspDelayThreshold) {
+	if l.DelayThreshold > 1.0 && flow.receiveNext > flow.signalNext &&
+		l.rtt > Clock(float64(l.minRtt)*l.DelayThreshold) {
 		if exit = l.advance("delay", flow, node); exit {
 			return
 		}
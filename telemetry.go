@@ -1,26 +1,4 @@
-package main
-
-// Telemetry contains data set on packets for telemetry-based CCAs.
-type Telemetry struct {
-	Sojourn Clock // time between enqueue and dequeue
-	QLen    Bytes // queue length in bytes before packet is enqueued
-	DQLen   Bytes // queue length in bytes after packet is dequeued
-	PktLen  Bytes // packet length (could have grown due to encapsulation)
-	Total   Bytes // total bytes sent by bottleneck
-}
-
-// Merge combines newer Telemetry data for delayed ACK logic. The newer values
-// are taken for all fields except PktLen, which is summed. NOTE We could also
-// take average values for sojourn and qlen here.
-func (t Telemetry) Merge(newer Telemetry) Telemetry {
-	return Telemetry{
-		newer.Sojourn,
-		newer.QLen,
-		newer.DQLen,
-		t.PktLen + newer.PktLen,
-		newer.Total,
-	}
-}
+package scim
 
 // TelemetryQueue is an AQM that measures and sets telemetry data.
 type TelemetryQueue struct {
@@ -124,7 +102,8 @@ func (s *Stuttgart) handleTelemetry(tel Telemetry, flow *Flow, node Node) {
 		// before cwnd targeting took place.
 		if s.priorQLen > 0 && flow.receiveNext < s.preTargetSeq {
 			flow.setCWND(s.preTargetCwnd, node)
-			//node.Logf("restore cwnd")
+			flow.traceState(node, "target", "restore",
+				"cwnd", s.preTargetCwnd)
 		}
 	} else {
 		// If changing state from QLen == 0 to QLen > 0, record the cwnd and
@@ -132,7 +111,8 @@ func (s *Stuttgart) handleTelemetry(tel Telemetry, flow *Flow, node Node) {
 		if s.priorQLen == 0 {
 			s.preTargetCwnd = flow.cwnd
 			s.preTargetSeq = flow.seq
-			//node.Logf("record cwnd")
+			flow.traceState(node, "target", "record",
+				"cwnd", s.preTargetCwnd, "seq", s.preTargetSeq)
 		}
 
 		// Do cwnd targeting by rewinding cwnd to one RTT ago (since telemetry
@@ -142,12 +122,25 @@ func (s *Stuttgart) handleTelemetry(tel Telemetry, flow *Flow, node Node) {
 		qp := float64(tel.Sojourn) / float64(flow.rtt)
 		fqp := qp * float64(tel.PktLen) / float64(sent)
 		cwnd1RttAgo := flow.cwndWin.at(node.Now() - flow.rtt)
-		//cwnd0 := flow.cwnd
+		cwnd0 := flow.cwnd
 		cwnd1 := cwnd1RttAgo - Bytes(float64(cwnd1RttAgo)*float64(fqp))
 		flow.setCWND(cwnd1, node)
 
-		//node.Logf("flow:%d sent:%d tot:%d ptot:%d qp:%f soj:%d rtt:%d fqp:%f len:%d cwnd1RttAgo:%d cwnd0:%d cwnd1:%d",
-		//	flow.id, sent, tel.Total, s.priorTotal, qp, tel.Sojourn, flow.rtt, fqp, tel.PktLen, cwnd1RttAgo, cwnd0, cwnd1)
+		if flow.tracing(TraceState) {
+			flow.traceState(node,
+				"target", "cwnd",
+				"sent", sent,
+				"total", tel.Total,
+				"prior_total", s.priorTotal,
+				"qp", qp,
+				"sojourn", tel.Sojourn,
+				"rtt", flow.rtt,
+				"fqp", fqp,
+				"pkt_len", tel.PktLen,
+				"cwnd_1rtt_ago", cwnd1RttAgo,
+				"cwnd0", cwnd0,
+				"cwnd1", cwnd1)
+		}
 	}
 	s.priorQLen = tel.QLen
 	s.priorTotal = tel.Total
@@ -162,7 +155,7 @@ func (s *Stuttgart) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
 
 	// Reno growth- sequence number based
 	if flow.receiveNext >= s.priorGrowth {
-		flow.setCWND(flow.cwnd+MSS, node)
+		flow.setCWND(flow.cwnd+flow.mss(), node)
 		s.priorGrowth = flow.seq
 	}
 
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TraceCategory is a category of structured trace events.  Categories are
+// bit flags, so more than one may be selected.
+type TraceCategory int
+
+const (
+	TraceCwnd          TraceCategory = 1 << iota // cwnd changes, with cause
+	TraceSlowStartExit                           // slow-start exits, with reason
+	TraceStage                                   // CCA stage changes
+	TraceSignalIgnored                           // signals with no response
+	TraceProbe                                   // bandwidth or RTT probes
+	TraceState                                   // CCA internal state
+	TraceNone          TraceCategory = 0
+	TraceAll                         = TraceCwnd | TraceSlowStartExit |
+		TraceStage | TraceSignalIgnored | TraceProbe | TraceState
+)
+
+// traceCategoryName maps trace categories to names, in order.
+var traceCategoryName = []struct {
+	category TraceCategory
+	name     string
+}{
+	{TraceCwnd, "cwnd"},
+	{TraceSlowStartExit, "ss-exit"},
+	{TraceStage, "stage"},
+	{TraceSignalIgnored, "signal-ignored"},
+	{TraceProbe, "probe"},
+	{TraceState, "state"},
+}
+
+// String implements fmt.Stringer.
+func (c TraceCategory) String() string {
+	var n []string
+	for _, t := range traceCategoryName {
+		if c&t.category != 0 {
+			n = append(n, t.name)
+		}
+	}
+	return strings.Join(n, ",")
+}
+
+// ParseTraceCategories parses a comma separated list of category names, or
+// "all" for all categories.
+func ParseTraceCategories(s string) (c TraceCategory, err error) {
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.TrimSpace(n); n == "" {
+			continue
+		}
+		if n == "all" {
+			c |= TraceAll
+			continue
+		}
+		var ok bool
+		for _, t := range traceCategoryName {
+			if t.name == n {
+				c |= t.category
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			err = fmt.Errorf("unknown trace category '%s' (valid: %s,all)",
+				n, TraceAll)
+			return
+		}
+	}
+	return
+}
+
+// Tracer writes structured trace events to a JSONL file, one event per line,
+// for the selected categories.
+type Tracer struct {
+	categories TraceCategory
+	file       *os.File
+	writer     *bufio.Writer
+	encoder    *json.Encoder
+}
+
+// Open creates the named file, and enables tracing for the given categories.
+func (t *Tracer) Open(name string, categories TraceCategory) (err error) {
+	if t.file, err = os.Create(name); err != nil {
+		return
+	}
+	t.writer = bufio.NewWriter(t.file)
+	t.encoder = json.NewEncoder(t.writer)
+	t.categories = categories
+	return
+}
+
+// Close flushes and closes the file.
+func (t *Tracer) Close() (err error) {
+	if t.file == nil {
+		return
+	}
+	if err = t.writer.Flush(); err != nil {
+		return
+	}
+	err = t.file.Close()
+	t.file = nil
+	t.categories = TraceNone
+	return
+}
+
+// enabled returns true if tracing is enabled for the given category.
+func (t *Tracer) enabled(category TraceCategory) bool {
+	return t != nil && t.categories&category != 0
+}
+
+// write writes an event.
+func (t *Tracer) write(event any) {
+	if err := t.encoder.Encode(event); err != nil {
+		panic(fmt.Sprintf("trace: %s", err))
+	}
+}
+
+// traceEvent contains the fields common to all trace events.
+type traceEvent struct {
+	Clock Clock  `json:"clock"` // nanoseconds
+	Flow  FlowID `json:"flow"`
+	Event string `json:"event"`
+}
+
+// cwndEvent records a cwnd change.  If the requested cwnd was raised to the
+// minimum, Clamped is the number of bytes added by clamping.
+type cwndEvent struct {
+	traceEvent
+	Cwnd0   Bytes     `json:"cwnd0"`
+	Cwnd    Bytes     `json:"cwnd"`
+	Cause   cwndCause `json:"cause"`
+	Clamped Bytes     `json:"clamped,omitempty"`
+}
+
+// slowStartExitEvent records a slow-start exit.
+type slowStartExitEvent struct {
+	traceEvent
+	SlowStart string `json:"slow_start"`
+	Reason    string `json:"reason"`
+	Cwnd0     Bytes  `json:"cwnd0"`
+	Cwnd      Bytes  `json:"cwnd"`
+}
+
+// stageEvent records a change in a CCA's internal stage or mode.
+type stageEvent struct {
+	traceEvent
+	CCA  string `json:"cca"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// signalIgnoredEvent records a congestion signal that had no response.
+type signalIgnoredEvent struct {
+	traceEvent
+	Signal string `json:"signal"`
+	Reason string `json:"reason"`
+}
+
+// probeEvent records the start of a bandwidth or RTT probe.
+type probeEvent struct {
+	traceEvent
+	CCA   string `json:"cca"`
+	Probe string `json:"probe"`
+}
+
+// stateEvent records arbitrary CCA internal state.
+type stateEvent struct {
+	traceEvent
+	CCA   string         `json:"cca"`
+	State map[string]any `json:"state"`
+}
+
+// event returns a traceEvent for the flow.
+func (f *Flow) event(name string, node Node) traceEvent {
+	return traceEvent{node.Now(), f.id, name}
+}
+
+// ccaName returns the type name of the current slow-start algorithm or CCA.
+func (f *Flow) ccaName() string {
+	if f.state == FlowStateSS {
+		return fmt.Sprintf("%T", f.slowStart)
+	}
+	return fmt.Sprintf("%T", f.cca)
+}
+
+// traceCwnd traces a change in cwnd from cwnd0, given the requested cwnd
+// before clamping.
+func (f *Flow) traceCwnd(cwnd0, requested Bytes, node Node) {
+	if !f.tracer.enabled(TraceCwnd) || f.cwnd == cwnd0 {
+		return
+	}
+	f.tracer.write(cwndEvent{f.event("cwnd", node), cwnd0, f.cwnd, f.cause,
+		f.cwnd - requested})
+}
+
+// traceSlowStartExit traces a slow-start exit for the given reason.
+func (f *Flow) traceSlowStartExit(reason string, cwnd0 Bytes, node Node) {
+	if !f.tracer.enabled(TraceSlowStartExit) {
+		return
+	}
+	f.tracer.write(slowStartExitEvent{f.event("ss-exit", node),
+		fmt.Sprintf("%T", f.slowStart), reason, cwnd0, f.cwnd})
+}
+
+// traceStage traces a change in a CCA's stage, for CCAs with multiple stages
+// or modes.
+func (f *Flow) traceStage(from, to any, node Node) {
+	if !f.tracer.enabled(TraceStage) {
+		return
+	}
+	f.tracer.write(stageEvent{f.event("stage", node), f.ccaName(),
+		fmt.Sprint(from), fmt.Sprint(to)})
+}
+
+// traceSignal traces a congestion signal as ignored if it had no effect on
+// cwnd, the pacing rate or the flow state, given their values before the
+// signal was handled.  The reason is cwr if the signal arrived within the
+// signal window, or cca otherwise.
+func (f *Flow) traceSignal(signal string, cwnd0 Bytes, rate0 Bitrate,
+	state0 FlowState, node Node) {
+	if !f.tracer.enabled(TraceSignalIgnored) || f.cwnd != cwnd0 ||
+		f.pacingRate != rate0 || f.state != state0 {
+		return
+	}
+	r := "cca"
+	if f.receiveNext <= f.signalNext {
+		r = "cwr"
+	}
+	f.tracer.write(signalIgnoredEvent{f.event("signal-ignored", node),
+		signal, r})
+}
+
+// traceProbe traces the start of a bandwidth or RTT probe.
+func (f *Flow) traceProbe(probe string, node Node) {
+	if !f.tracer.enabled(TraceProbe) {
+		return
+	}
+	f.tracer.write(probeEvent{f.event("probe", node), f.ccaName(), probe})
+}
+
+// traceState traces CCA internal state, given as alternating keys and values.
+// As the arguments are evaluated even when tracing is disabled, callers with
+// costly arguments should check tracing(TraceState) first.
+func (f *Flow) traceState(node Node, keyValues ...any) {
+	if !f.tracer.enabled(TraceState) {
+		return
+	}
+	m := make(map[string]any, len(keyValues)/2)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		m[fmt.Sprint(keyValues[i])] = keyValues[i+1]
+	}
+	f.tracer.write(stateEvent{f.event("state", node), f.ccaName(), m})
+}
+
+// tracing returns true if tracing is enabled for the given category.
+func (f *Flow) tracing(category TraceCategory) bool {
+	return f.tracer.enabled(category)
+}
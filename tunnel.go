@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+// Per-packet encapsulation overheads for common tunnels, over IPv4.
+const (
+	VXLANOverhead     = Bytes(14 + 20 + 8 + 8) // Ethernet + IPv4 + UDP + VXLAN
+	WireGuardOverhead = Bytes(20 + 8 + 32)     // IPv4 + UDP + WireGuard
+	PPPoEOverhead     = Bytes(6 + 2)           // PPPoE + PPP
+)
+
+// Tunnel is a Handler that adds per-packet encapsulation overhead to data
+// packets, or removes it, so a path segment between an encapsulating and a
+// decapsulating Tunnel carries the larger packets.  The endpoints keep their
+// own MSS, so the bottleneck serializes the encapsulated packets, and AQMs and
+// telemetry see their full length.  Packets are never fragmented, so the
+// encapsulated length may exceed the MTU of the underlying link, which is
+// counted.  As in real deployments, this may be avoided by reducing the flows'
+// MTUs by the overhead (see FlowMTU).
+type Tunnel struct {
+	overhead Bytes
+	linkMTU  Bytes
+	decap    bool
+	total    int
+	overMTU  int
+}
+
+// NewTunnelEncap returns a new Tunnel that adds the given overhead, for an
+// underlying link with the given MTU.
+func NewTunnelEncap(overhead, linkMTU Bytes) *Tunnel {
+	return &Tunnel{
+		overhead, // overhead
+		linkMTU,  // linkMTU
+		false,    // decap
+		0,        // total
+		0,        // overMTU
+	}
+}
+
+// NewTunnelDecap returns a new Tunnel that removes the given overhead, which
+// should be the same as for the encapsulating Tunnel.
+func NewTunnelDecap(overhead Bytes) *Tunnel {
+	return &Tunnel{
+		overhead, // overhead
+		0,        // linkMTU
+		true,     // decap
+		0,        // total
+		0,        // overMTU
+	}
+}
+
+// Handle implements Handler.
+func (t *Tunnel) Handle(pkt Packet, node Node) error {
+	if !pkt.ACK {
+		t.total++
+		if t.decap {
+			pkt.Len -= t.overhead
+		} else {
+			pkt.Len += t.overhead
+			if pkt.Len > t.linkMTU {
+				t.overMTU++
+			}
+		}
+	}
+	node.Send(pkt)
+	return nil
+}
+
+// Stop implements Stopper.
+func (t *Tunnel) Stop(node Node) error {
+	if t.decap {
+		node.Logf("tunnel: decapsulated %d packets, removing %d bytes each",
+			t.total, t.overhead)
+	} else {
+		node.Logf("tunnel: encapsulated %d packets, adding %d bytes each, "+
+			"%d over link MTU %d", t.total, t.overhead, t.overMTU, t.linkMTU)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+import (
+	"github.com/heistp/scim/aqm"
+	"github.com/heistp/scim/plot"
+	"github.com/heistp/scim/sim"
+)
+
+// Types and constants from the sim, plot and aqm packages, so they may be used
+// unqualified here and in config.go.
+type (
+	Clock        = sim.Clock
+	Sim          = sim.Sim
+	Node         = sim.Node
+	Handler      = sim.Handler
+	Starter      = sim.Starter
+	Dinger       = sim.Dinger
+	Stopper      = sim.Stopper
+	Packet       = sim.Packet
+	FlowID       = sim.FlowID
+	Seq          = sim.Seq
+	SCECapable   = sim.SCECapable
+	ECNCodepoint = sim.ECNCodepoint
+	Telemetry    = sim.Telemetry
+	Bytes        = sim.Bytes
+	Bitrate      = sim.Bitrate
+	Xplot        = plot.Xplot
+	Axis         = plot.Axis
+	AQM          = aqm.AQM
+	color        = plot.Color
+)
+
+const (
+	ClockMax = sim.ClockMax
+
+	SCE   = sim.SCE
+	NoSCE = sim.NoSCE
+
+	NotECT = sim.NotECT
+	ECT1   = sim.ECT1
+	ECT0   = sim.ECT0
+	CE     = sim.CE
+
+	Byte     = sim.Byte
+	Kilobyte = sim.Kilobyte
+	Megabyte = sim.Megabyte
+	Gigabyte = sim.Gigabyte
+	Kibibyte = sim.Kibibyte
+	Mebibyte = sim.Mebibyte
+	Gibibyte = sim.Gibibyte
+	MaxBytes = sim.MaxBytes
+
+	Bps  = sim.Bps
+	Yps  = sim.Yps
+	Kbps = sim.Kbps
+	Mbps = sim.Mbps
+	Gbps = sim.Gbps
+	Tbps = sim.Tbps
+
+	colorWhite  = plot.White
+	colorGreen  = plot.Green
+	colorRed    = plot.Red
+	colorYellow = plot.Yellow
+)
+
+var (
+	NewSim       = sim.NewSim
+	CalcBitrate  = sim.CalcBitrate
+	TransferTime = sim.TransferTime
+)
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+// Copyright 2025 Pete Heist
+
+package scim
+
+// Vegas implements TCP Vegas, mostly as in Linux tcp_vegas.c.  Once per RTT,
+// the number of packets queued at the bottleneck (diff) is estimated from the
+// expected and actual rates, using the minimum RTT seen over the round and the
+// base (min) RTT.  Cwnd is then increased by one MSS if diff is below
+// VegasAlpha, or decreased by one MSS if above VegasBeta.  Vegas does its own
+// slow-start, growing per ACK and exiting when diff exceeds VegasGamma, so
+// Vegas flows should use NoSS.  As there is no loss, CE is treated as loss.
+type Vegas struct {
+	slowStart bool
+	begSndNxt Seq
+	minRtt    Clock
+	cntRtt    int
+}
+
+// NewVegas returns a new Vegas.
+func NewVegas() *Vegas {
+	return &Vegas{
+		true,     // slowStart
+		0,        // begSndNxt
+		ClockMax, // minRtt
+		0,        // cntRtt
+	}
+}
+
+// slowStartExit implements slowStartExiter.
+func (v *Vegas) slowStartExit(flow *Flow, node Node) {
+	v.begSndNxt = flow.seq
+}
+
+// handleCE implements handleCEer.
+func (v *Vegas) handleCE(flow *Flow, node Node) {
+	if flow.receiveNext > flow.signalNext {
+		flow.setCWND(Bytes(float64(flow.cwnd)*CEMD), node)
+		flow.signalNext = flow.seq
+		v.exitSlowStart(flow, node, "CE")
+	}
+}
+
+// updateRtt implements updateRtter.
+func (v *Vegas) updateRtt(rtt Clock, flow *Flow, node Node) {
+	v.minRtt = min(v.minRtt, rtt)
+	v.cntRtt++
+}
+
+// grow implements CCA.
+func (v *Vegas) grow(acked Bytes, pkt Packet, flow *Flow, node Node) {
+	if pkt.ECE {
+		return
+	}
+	if flow.receiveNext <= v.begSndNxt {
+		if v.slowStart {
+			flow.setCWND(flow.cwnd+acked, node)
+		}
+		return
+	}
+	v.begSndNxt = flow.seq
+	if v.cntRtt <= 2 {
+		// too few RTT samples to be reliable, so fall back to Reno
+		if v.slowStart {
+			flow.setCWND(flow.cwnd+acked, node)
+		} else {
+			flow.setCWND(flow.cwnd+acked*flow.mss()/flow.cwnd, node)
+		}
+	} else {
+		v.adjust(acked, flow, node)
+	}
+	v.minRtt = ClockMax
+	v.cntRtt = 0
+}
+
+// adjust performs the once per RTT cwnd adjustment.
+func (v *Vegas) adjust(acked Bytes, flow *Flow, node Node) {
+	b := flow.minRtt
+	t := flow.cwnd * Bytes(b) / Bytes(v.minRtt) // target cwnd
+	d := float64(flow.cwnd) * float64(v.minRtt-b) / float64(b) / float64(flow.mss())
+	if v.slowStart {
+		if d > VegasGamma {
+			flow.setCWND(min(flow.cwnd, t+flow.mss()), node)
+			v.exitSlowStart(flow, node, "diff")
+		} else {
+			flow.setCWND(flow.cwnd+acked, node)
+		}
+		return
+	}
+	if d > VegasBeta {
+		flow.setCWND(flow.cwnd-flow.mss(), node)
+	} else if d < VegasAlpha {
+		flow.setCWND(flow.cwnd+flow.mss(), node)
+	}
+}
+
+// exitSlowStart exits the internal slow-start.
+func (v *Vegas) exitSlowStart(flow *Flow, node Node, reason string) {
+	if !v.slowStart {
+		return
+	}
+	v.slowStart = false
+	node.Logf("flow:%d vegas slow-start exit %s cwnd:%d minrtt:%sms",
+		flow.id, reason, flow.cwnd, flow.minRtt.StringMS())
+	flow.traceStage("slow-start", "congestion-avoidance", node)
+}